@@ -1,11 +1,15 @@
 package basic
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"os"
 	"pimtrace/ast"
 	"pimtrace/dataformats/maildata"
+	"pimtrace/fsys"
 	"reflect"
-	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/arran4/go-evaluator"
@@ -19,6 +23,8 @@ var (
 	ErrUnknownIntoStatement      = fmt.Errorf("unknown into")
 	ErrIntoNotImplemented        = fmt.Errorf("into not implemented")
 	ErrInvalidFunctionExpression = fmt.Errorf("invalid function expression")
+	ErrUnbalancedParenthesis     = fmt.Errorf("unbalanced parenthesis")
+	ErrInvalidCount              = fmt.Errorf("invalid count")
 )
 
 // EvaluatorFunctions removed for thread safety
@@ -26,49 +32,108 @@ var (
 type FilterEquals string
 type FilterContains string
 type FilterIContains string
+type FilterBetween string
+type FilterIn string
+
+// FilterListFile is an `@file` list of values for `in`
+type FilterListFile string
+
+// FilterOperator is any other operator registered in ast.Ops
+type FilterOperator string
 type FilterNot string
+type FilterAnd string
+type FilterOr string
+type FilterOpen string
+type FilterClose string
 type Terminator string
 
 var _ ast.ValueExpression = ast.ConstantExpression("")
 var _ ast.ValueExpression = ast.EntryExpression("")
 
 func FilterIdentify(s string) (any, error) {
+	if isQuoted(s) {
+		v, err := ParseValueExpression(s)
+		if err != nil {
+			return nil, fmt.Errorf("filter tokenizer: %w", err)
+		}
+		return v, nil
+	}
 	ss := strings.SplitN(s, ".", 2)
 	switch ss[0] {
-	case "into", "filter", "where", "sort":
+	case "into", "filter", "where", "sort", "limit", "offset", "top", "distinct", "having", "extend", "drop", "rename":
 		return Terminator(s), nil
 	case "not":
 		return FilterNot(s), nil
+	case "and":
+		return FilterAnd(s), nil
+	case "or":
+		return FilterOr(s), nil
+	case "(":
+		return FilterOpen(s), nil
+	case ")":
+		return FilterClose(s), nil
 	case "eq":
 		return FilterEquals(s), nil
 	case "contains":
 		return FilterContains(s), nil
 	case "icontains":
 		return FilterIContains(s), nil
+	case "between":
+		return FilterBetween(s), nil
+	case "in":
+		return FilterIn(s), nil
 	case "h", "header":
 		return ast.EntryExpression(s), nil
 	case "c", "column":
 		return ast.EntryExpression(s), nil
+	case "p", "property":
+		return ast.EntryExpression(s), nil
+	case "f", "func":
+		v, _, err := ParseFunctionExpression([]string{s})
+		if err != nil {
+			return nil, fmt.Errorf("filter tokenizer: %w", err)
+		}
+		return v, nil
 	case "":
 		if strings.HasPrefix(s, ".") {
 			return ast.ConstantExpression(ss[1]), nil
 		}
 	}
+	if _, ok := ast.Ops[s]; ok {
+		return FilterOperator(s), nil
+	}
+	if strings.HasPrefix(s, "@") {
+		return FilterListFile(s[1:]), nil
+	}
 	return nil, fmt.Errorf("filter tokenizer: %w: %s", ErrParserUnknownToken, ss[0])
 }
 
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, `"`) || strings.HasPrefix(s, `'`)
+}
+
 func IntoIdentify(args []string) (any, []string, error) {
 	if len(args) == 0 {
 		return nil, args, nil
 	}
+	if isQuoted(args[0]) {
+		s, remain := joinExpression(args)
+		v, err := ParseValueExpression(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("into tokenizer: %w", err)
+		}
+		return v, remain, nil
+	}
 	ss := strings.SplitN(args[0], ".", 2)
 	switch ss[0] {
-	case "into", "filter", "where", "sort", "calculate":
+	case "into", "filter", "where", "sort", "calculate", "limit", "offset", "top", "distinct", "having", "extend", "drop", "rename":
 		return Terminator(args[0]), args[0:], nil
 	case "h", "header":
 		return ast.EntryExpression(args[0]), args[1:], nil
 	case "c", "column":
 		return ast.EntryExpression(args[0]), args[1:], nil
+	case "p", "property":
+		return ast.EntryExpression(args[0]), args[1:], nil
 	case "f", "func":
 		return ParseFunctionExpression(args)
 	case "":
@@ -79,101 +144,47 @@ func IntoIdentify(args []string) (any, []string, error) {
 	return nil, nil, fmt.Errorf("into tokenizer: %w: %s", ErrParserUnknownToken, ss[0])
 }
 
+// FunctionParameterExpressionIdentify parses the function argument at the start of args.
 func FunctionParameterExpressionIdentify(args []string) (any, []string, error) {
 	if len(args) == 0 {
 		return nil, args, nil
 	}
-	ss := strings.SplitN(args[0], ".", 2)
-	switch ss[0] {
-	case "h", "header":
-		return ast.EntryExpression(args[0]), args[1:], nil
-	case "c", "column":
-		return ast.EntryExpression(args[0]), args[1:], nil
-	case "f", "func":
-		return ParseFunctionExpression(args)
-	case "":
-		if strings.HasPrefix(args[0], ".") {
-			return ast.ConstantExpression(ss[1]), args[1:], nil
-		}
+	s, remain := joinExpression(args)
+	v, err := ParseValueExpression(s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("function param tokenizer: %w", err)
 	}
-	return nil, nil, fmt.Errorf("function param tokenizer: %w: %s", ErrParserUnknownToken, ss[0])
+	return v, remain, nil
 }
 
-var fere = regexp.MustCompile(`^(f|func)\.([^[]+)(\[([^]]+)\])?$`)
-
+// ParseFunctionExpression parses the function call at the start of args, joining any arguments the shell split it over.
 func ParseFunctionExpression(args []string) (ast.ValueExpression, []string, error) {
-	m := fere.FindStringSubmatch(args[0])
-	if len(m) == 5 {
-		var params []ast.ValueExpression
-		if len(m[3]) > 0 {
-			var err error
-			params, err = ParseExpressions(m[4])
-			if err != nil {
-				return nil, nil, fmt.Errorf("parameter parse error: %w", err)
-			}
-		}
-
-		// Check if it is an Evaluator Function (standard list or heuristic)
-		// We hardcode known evaluator functions here or rely on runtime resolution.
-		if isEvaluatorFunction(m[2]) {
-			// Convert params to []evaluator.Term
-			var terms []evaluator.Term
-			for _, p := range params {
-				terms = append(terms, p)
-			}
-			return &ast.EvaluatorFunctionExpression{
-				Function: m[2],
-				FunctionExpression: evaluator.FunctionExpression{
-					// Func is resolved at runtime via Context
-					Args: terms,
-				},
-			}, args[1:], nil
-		}
-
-		return &ast.FunctionExpression{
-			Function: m[2],
-			Args:     params,
-		}, args[1:], nil
+	s, remain := joinExpression(args)
+	if !isFunctionWord(s) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidFunctionExpression, s)
 	}
-	return nil, nil, fmt.Errorf("%w: %s", ErrInvalidFunctionExpression, args[0])
-}
-
-func ParseExpressions(s string) ([]ast.ValueExpression, error) {
-	css := strings.SplitN(s, ",", 2)
-	var results []ast.ValueExpression
-	for len(css) > 0 {
-		var err error
-		var v any
-		v, css, err = FunctionParameterExpressionIdentify(css)
-		if err != nil {
-			return nil, err
-		}
-		switch v := v.(type) {
-		case ast.ValueExpression:
-			results = append(results, v)
-		default:
-			return nil, fmt.Errorf("%w: %s", ErrParserUnknownToken, css[0])
-		}
+	v, err := ParseValueExpression(s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFunctionExpression, err)
 	}
-	return results, nil
+	return v, remain, nil
 }
 
 func FilterTokenizerScanN(args []string, n int) ([]any, []string, error) {
-	i := 0
 	r := []any{}
-done:
-	for ; i < n && i < len(args); i++ {
-		t, err := FilterIdentify(args[i])
+	for len(r) < n && len(args) > 0 {
+		s, remain := joinExpression(args)
+		t, err := FilterIdentify(s)
 		if err != nil {
 			return nil, nil, err
 		}
-		switch t.(type) {
-		case Terminator:
-			break done
+		if _, ok := t.(Terminator); ok {
+			break
 		}
 		r = append(r, t)
+		args = remain
 	}
-	return r, args[i:], nil
+	return r, args, nil
 }
 
 func IntoTokenizerScan(args []string) ([]any, []string, error) {
@@ -195,14 +206,64 @@ done:
 	return r, args, nil
 }
 
-func ParseFilter(args []string, statements []ast.Operation) (*evaluator.Query, []string, error) {
-	tks, remain, err := FilterTokenizerScanN(args, 3)
+// ParseFilter parses a condition. Conditions can be combined with `and` and `or` and grouped with `(` and `)`; `not`
+// binds tightest, then `and`, then `or`.
+//
+// Options: fsys.FS used to read `@file` lists.
+func ParseFilter(args []string, statements []ast.Operation, ops ...any) (*evaluator.Query, []string, error) {
+	return parseFilterOr(args, ops)
+}
+
+func parseFilterOr(args []string, ops []any) (*evaluator.Query, []string, error) {
+	q, remain, err := parseFilterAnd(args, ops)
 	if err != nil {
 		return nil, nil, err
 	}
-	if TokenMatcher(tks, FilterNot("")) != nil {
-		var op *evaluator.Query
-		op, remain, err = ParseFilter(args[1:], []ast.Operation{})
+	qs := []evaluator.Query{*q}
+	for isFilterToken(remain, FilterOr("")) {
+		q, remain, err = parseFilterAnd(remain[1:], ops)
+		if err != nil {
+			return nil, nil, fmt.Errorf("after or: %w", err)
+		}
+		qs = append(qs, *q)
+	}
+	if len(qs) == 1 {
+		return q, remain, nil
+	}
+	return &evaluator.Query{
+		Expression: &evaluator.OrExpression{
+			Expressions: qs,
+		},
+	}, remain, nil
+}
+
+func parseFilterAnd(args []string, ops []any) (*evaluator.Query, []string, error) {
+	q, remain, err := parseFilterUnary(args, ops)
+	if err != nil {
+		return nil, nil, err
+	}
+	qs := []evaluator.Query{*q}
+	for isFilterToken(remain, FilterAnd("")) {
+		q, remain, err = parseFilterUnary(remain[1:], ops)
+		if err != nil {
+			return nil, nil, fmt.Errorf("after and: %w", err)
+		}
+		qs = append(qs, *q)
+	}
+	if len(qs) == 1 {
+		return q, remain, nil
+	}
+	return &evaluator.Query{
+		Expression: &evaluator.AndExpression{
+			Expressions: qs,
+		},
+	}, remain, nil
+}
+
+func parseFilterUnary(args []string, ops []any) (*evaluator.Query, []string, error) {
+	switch {
+	case isFilterToken(args, FilterNot("")):
+		op, remain, err := parseFilterUnary(args[1:], ops)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -211,38 +272,62 @@ func ParseFilter(args []string, statements []ast.Operation) (*evaluator.Query, [
 				Expression: *op,
 			},
 		}, remain, nil
+	case isFilterToken(args, FilterOpen("")):
+		op, remain, err := parseFilterOr(args[1:], ops)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isFilterToken(remain, FilterClose("")) {
+			return nil, nil, fmt.Errorf("at %v: %w: missing )", args, ErrUnbalancedParenthesis)
+		}
+		return op, remain[1:], nil
+	case isFilterToken(args, FilterClose("")):
+		return nil, nil, fmt.Errorf("at %v: %w: unexpected )", args, ErrUnbalancedParenthesis)
+	}
+	return parseFilterComparison(args, ops)
+}
+
+func isFilterToken(args []string, t any) bool {
+	if len(args) == 0 {
+		return false
+	}
+	v, err := FilterIdentify(args[0])
+	return err == nil && reflect.TypeOf(v) == reflect.TypeOf(t)
+}
+
+var filterOperandTokens = []any{ast.EntryExpression(""), ast.ConstantExpression(""), (*ast.FunctionExpression)(nil), (*ast.EvaluatorFunctionExpression)(nil)}
+
+func parseFilterComparison(args []string, ops []any) (*evaluator.Query, []string, error) {
+	// The list of in isn't a filter token, so in is found before scanning for it
+	if tks, _, err := FilterTokenizerScanN(args, 2); err == nil {
+		if matches := TokenMatcher(tks, filterOperandTokens, []any{FilterIn(""), FilterNot("")}); len(matches) == 2 {
+			return parseFilterIn(args, ops)
+		}
+	}
+	tks, remain, err := FilterTokenizerScanN(args, 3)
+	if err != nil {
+		return nil, nil, err
+	}
+	if matches := TokenMatcher(tks, filterOperandTokens, []any{FilterBetween("")}); len(matches) == 2 {
+		return parseFilterBetween(args)
 	}
 	if matches := TokenMatcher(tks,
-		[]any{ast.EntryExpression(""), ast.ConstantExpression("")},
-		[]any{FilterEquals(""), FilterContains(""), FilterIContains("")},
-		[]any{ast.EntryExpression(""), ast.ConstantExpression("")},
-	); len(matches) > 1 {
-		lhs := tks[0]
-		rhs := tks[2]
+		filterOperandTokens,
+		[]any{FilterEquals(""), FilterContains(""), FilterIContains(""), FilterOperator("")},
+		filterOperandTokens,
+	); len(matches) == 3 {
+		// Text matching of a field can use the evaluator's own lookups, eq is an Op so it collates the same as ne
 		var field string
 		var value interface{}
-
-		if l, ok := lhs.(ast.EntryExpression); ok {
-			if r, ok := rhs.(ast.ConstantExpression); ok {
-				field = l.ColumnName()
+		if l, ok := tks[0].(ast.EntryExpression); ok {
+			if r, ok := tks[2].(ast.ConstantExpression); ok {
+				field = evaluatorField(l)
 				value = string(r)
 			}
-		} else if l, ok := lhs.(ast.ConstantExpression); ok {
-			if r, ok := rhs.(ast.EntryExpression); ok {
-				field = r.ColumnName()
-				value = string(l)
-			}
 		}
 
 		if field != "" {
 			switch matches[1].(type) {
-			case FilterEquals:
-				return &evaluator.Query{
-					Expression: &evaluator.IsExpression{
-						Field: field,
-						Value: value,
-					},
-				}, remain, nil
 			case FilterContains:
 				return &evaluator.Query{
 					Expression: &evaluator.ContainsExpression{
@@ -268,6 +353,17 @@ func ParseFilter(args []string, statements []ast.Operation) (*evaluator.Query, [
 			op = "contains"
 		case FilterIContains:
 			op = "icontains"
+		case FilterOperator:
+			op = string(tks[1].(FilterOperator))
+		}
+		if pattern, ok := tks[2].(ast.ConstantExpression); ok && ast.IsPatternOp(op) {
+			m, err := ast.NewMatch(op, tks[0].(ast.ValueExpression), string(pattern))
+			if err != nil {
+				return nil, nil, fmt.Errorf("at %v: %w", tks, err)
+			}
+			return &evaluator.Query{
+				Expression: m,
+			}, remain, nil
 		}
 		return &evaluator.Query{
 			Expression: &ast.Op{
@@ -280,7 +376,156 @@ func ParseFilter(args []string, statements []ast.Operation) (*evaluator.Query, [
 	return nil, nil, fmt.Errorf("at %v: %w", tks, ErrParserNothingFound)
 }
 
-func ParseIntoSummary(args []string) (ast.Operation, []string, error) {
+// parseFilterIn parses `value in .a,.b` and `value in @file` where file has one value per line. Either can be negated
+// with `value not in ...`.
+func parseFilterIn(args []string, ops []any) (*evaluator.Query, []string, error) {
+	tks, remain, err := FilterTokenizerScanN(args, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	n := 2
+	if len(tks) > 1 {
+		if _, negate := tks[1].(FilterNot); negate {
+			n = 3
+			if tks, remain, err = FilterTokenizerScanN(args, n); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if len(tks) != n || reflect.TypeOf(tks[n-1]) != reflect.TypeOf(FilterIn("")) {
+		return nil, nil, fmt.Errorf("at %v: %w: expected in after not", tks, ErrParserNothingFound)
+	}
+	if len(remain) == 0 {
+		return nil, nil, fmt.Errorf("at %v: %w: in needs a .value,.value list or an @file", tks, ErrParserNothingFound)
+	}
+	list, remain := joinList(remain)
+	var items []string
+	if strings.HasPrefix(list, "@") {
+		if items, err = readListFile(list[1:], ops); err != nil {
+			return nil, nil, fmt.Errorf("at %v: %w", tks, err)
+		}
+	} else if items, err = parseList(list); err != nil {
+		return nil, nil, fmt.Errorf("at %v: in needs a .value,.value list or an @file: %w", tks, err)
+	}
+	var q evaluator.Expression = ast.NewIn(tks[0].(ast.ValueExpression), items)
+	if n == 3 {
+		q = &evaluator.NotExpression{
+			Expression: evaluator.Query{
+				Expression: q,
+			},
+		}
+	}
+	return &evaluator.Query{
+		Expression: q,
+	}, remain, nil
+}
+
+// joinList joins a list with a quoted item which the shell split over several arguments, returning it and the
+// remaining arguments.
+func joinList(args []string) (string, []string) {
+	s := args[0]
+	n := 1
+	for ; n < len(args); n++ {
+		if _, err := lex(s, 1); !errors.Is(err, ErrUnterminatedString) {
+			break
+		}
+		s += " " + args[n]
+	}
+	return s, args[n:]
+}
+
+// parseList splits a list such as `.a,"b, c",.d` on its commas, so quoted items can contain them.
+func parseList(s string) ([]string, error) {
+	tokens, err := lex(s, 1)
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for i := 0; i < len(tokens); i += 2 {
+		t := tokens[i]
+		switch {
+		case t.Kind == TokenString:
+			items = append(items, t.Text)
+		case t.Kind == TokenWord && strings.HasPrefix(t.Text, "."):
+			items = append(items, t.Text[1:])
+		default:
+			return nil, &SyntaxError{Expression: s, Pos: t.Pos, Err: fmt.Errorf("%w: %s", ErrUnexpectedToken, t)}
+		}
+		switch sep := tokens[i+1]; sep.Kind {
+		case TokenEOF:
+			return items, nil
+		case TokenComma:
+		default:
+			return nil, &SyntaxError{Expression: s, Pos: sep.Pos, Err: fmt.Errorf("%w: %s", ErrUnexpectedToken, sep)}
+		}
+	}
+	return items, nil
+}
+
+// readListFile reads the non blank lines of fName, trimmed of surrounding white space.
+func readListFile(fName string, ops []any) ([]string, error) {
+	fs := fsys.NewOSFS()
+	for _, op := range ops {
+		if o, ok := op.(fsys.FS); ok {
+			fs = o
+		}
+	}
+	f, err := fs.OpenFile(fName, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("reading list %s: %w", fName, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var items []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if item := strings.TrimSpace(scanner.Text()); item != "" {
+			items = append(items, item)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading list %s: %w", fName, err)
+	}
+	return items, nil
+}
+
+// parseFilterBetween parses `value between low high`, low and high are inclusive.
+func parseFilterBetween(args []string) (*evaluator.Query, []string, error) {
+	tks, remain, err := FilterTokenizerScanN(args, 4)
+	if err != nil {
+		return nil, nil, err
+	}
+	if matches := TokenMatcher(tks, filterOperandTokens, []any{FilterBetween("")}, filterOperandTokens, filterOperandTokens); len(matches) != 4 {
+		return nil, nil, fmt.Errorf("at %v: %w: between needs a low and a high value", tks, ErrParserNothingFound)
+	}
+	return &evaluator.Query{
+		Expression: &ast.Between{
+			Value: tks[0].(ast.ValueExpression),
+			Low:   tks[2].(ast.ValueExpression),
+			High:  tks[3].(ast.ValueExpression),
+		},
+	}, remain, nil
+}
+
+// evaluatorField is the field name the evaluator looks e up by, or "" if e can't be looked up by its bare name such as
+// iCal properties or names ColumnName changes.
+func evaluatorField(e ast.EntryExpression) string {
+	prefix, name, _ := strings.Cut(string(e), ".")
+	switch prefix {
+	case "h", "header", "c", "column":
+		if e.ColumnName() == name {
+			return name
+		}
+	}
+	return ""
+}
+
+// ParseIntoSummary parses `into summary groups calculate columns having condition`, where calculate and having are
+// optional. The having condition filters the summary rows, see ast.Having.
+//
+// Options: fsys.FS used to read `@file` lists.
+func ParseIntoSummary(args []string, ops ...any) (ast.Operation, []string, error) {
 	results, remain, err := ParseIntoTable(args)
 	if err != nil {
 		return nil, nil, fmt.Errorf("summary table: %w", err)
@@ -324,6 +569,16 @@ func ParseIntoSummary(args []string) (ast.Operation, []string, error) {
 				return nil, nil, fmt.Errorf("at %v: %w: unexpected token type %s", tks, ErrParserFault, reflect.TypeOf(tkn))
 			}
 		}
+		if len(remain) > 0 && remain[0] == "having" {
+			var q *evaluator.Query
+			q, remain, err = ParseFilter(remain[1:], c.Statements, ops...)
+			if err != nil {
+				return nil, nil, fmt.Errorf("summary having: %w", err)
+			}
+			c.Statements = append(c.Statements, &ast.FilterStatement{
+				Expression: ast.Having(q, t.Columns[len(table.Columns):]),
+			})
+		}
 		if len(t.Columns) > len(table.Columns) {
 			c.Statements = append(c.Statements, t)
 		}
@@ -361,30 +616,203 @@ func ParseIntoTable(args []string) (ast.Operation, []string, error) {
 	return nil, nil, fmt.Errorf("at %v: %w", tks, ErrParserNothingFound)
 }
 
+// ParseSort parses sort keys, each of which can be preceded by `asc` or `desc`, or `-` for descending, and followed by
+// `nulls first` or `nulls last`, eg: `sort desc c.count nulls last asc c.From`.
 func ParseSort(args []string) (ast.Operation, []string, error) {
-	tks, remain, err := IntoTokenizerScan(args)
+	s, remain, err := parseSortKeys("sort", args, ast.SortOrder{})
 	if err != nil {
 		return nil, nil, err
 	}
-	if len(tks) > 0 {
-		var expressions []ast.ValueExpression
-	done:
-		for _, tkn := range tks {
-			switch tkn := tkn.(type) {
-			case ast.ValueExpression:
-				expressions = append(expressions, tkn)
-			case Terminator:
-				break done
-			default:
-				return nil, nil, fmt.Errorf("at %v: %w: unexpected token type %s", tks, ErrParserFault, reflect.TypeOf(tkn))
+	return s, remain, nil
+}
+
+// parseSortKeys parses the keys of ParseSort, keys without `asc` or `desc` are sorted by def.
+func parseSortKeys(name string, args []string, def ast.SortOrder) (*ast.SortTransformer, []string, error) {
+	result := &ast.SortTransformer{}
+	var orders []ast.SortOrder
+	order := def
+	pending := ""
+done:
+	for len(args) > 0 {
+		switch args[0] {
+		case "asc", "desc":
+			if pending != "" {
+				return nil, nil, fmt.Errorf("%s: %w: %s %s", name, ErrUnexpectedToken, pending, args[0])
 			}
+			pending = args[0]
+			order.Descending = args[0] == "desc"
+			args = args[1:]
+			continue
+		case "nulls":
+			if len(orders) == 0 || pending != "" || len(args) < 2 || args[1] != "first" && args[1] != "last" {
+				return nil, nil, fmt.Errorf("%s: %w: nulls must follow a sort key and be first or last", name, ErrUnexpectedToken)
+			}
+			orders[len(orders)-1].Nulls = ast.NullsFirst
+			if args[1] == "last" {
+				orders[len(orders)-1].Nulls = ast.NullsLast
+			}
+			args = args[2:]
+			continue
 		}
-		result := &ast.SortTransformer{
-			Expression: expressions,
+		key := args
+		if len(args[0]) > 1 && strings.HasPrefix(args[0], "-") && pending == "" {
+			key = append([]string{args[0][1:]}, args[1:]...)
+			order.Descending = true
+			pending = "-"
 		}
-		return result, remain, nil
+		t, remain, err := IntoIdentify(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		switch t := t.(type) {
+		case Terminator:
+			break done
+		case ast.ValueExpression:
+			result.Expression = append(result.Expression, t)
+			orders = append(orders, order)
+		default:
+			return nil, nil, fmt.Errorf("at %v: %w: unexpected token type %s", args, ErrParserFault, reflect.TypeOf(t))
+		}
+		order = def
+		pending = ""
+		args = remain
 	}
-	return nil, nil, fmt.Errorf("at %v: %w", tks, ErrParserNothingFound)
+	if pending != "" {
+		return nil, nil, fmt.Errorf("%s: %w: %s without a sort key", name, ErrUnexpectedToken, pending)
+	}
+	if len(result.Expression) == 0 {
+		return nil, nil, fmt.Errorf("at %v: %w", args, ErrParserNothingFound)
+	}
+	for _, o := range orders {
+		if o != (ast.SortOrder{}) {
+			result.Order = orders
+			break
+		}
+	}
+	return result, args, nil
+}
+
+// ParseLimit parses the count of `limit N` or `offset N`.
+func ParseLimit(args []string) (int, []string, error) {
+	if len(args) == 0 {
+		return 0, nil, fmt.Errorf("%w: missing count", ErrInvalidCount)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInvalidCount, args[0])
+	}
+	return n, args[1:], nil
+}
+
+// ParseTop parses `top N by keys`, the keys are the same as ParseSort's but default to descending so `top 10 by
+// c.count` keeps the 10 largest counts.
+func ParseTop(args []string) (ast.Operation, []string, error) {
+	n, remain, err := ParseLimit(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(remain) == 0 || remain[0] != "by" {
+		return nil, nil, fmt.Errorf("%w: expected by after top %d", ErrUnexpectedToken, n)
+	}
+	s, remain, err := parseSortKeys("top", remain[1:], ast.SortOrder{Descending: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ast.TopTransformer{
+		N:    n,
+		Sort: s,
+	}, remain, nil
+}
+
+// ParseDistinct parses the optional `by keys` of `distinct`.
+func ParseDistinct(args []string) (ast.Operation, []string, error) {
+	result := &ast.DistinctTransformer{}
+	if len(args) == 0 || args[0] != "by" {
+		return result, args, nil
+	}
+	tks, remain, err := IntoTokenizerScan(args[1:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("distinct: %w", err)
+	}
+	for _, tkn := range tks {
+		switch tkn := tkn.(type) {
+		case ast.ValueExpression:
+			result.Expression = append(result.Expression, tkn)
+		default:
+			return nil, nil, fmt.Errorf("at %v: %w: unexpected token type %s", tks, ErrParserFault, reflect.TypeOf(tkn))
+		}
+	}
+	if len(result.Expression) == 0 {
+		return nil, nil, fmt.Errorf("at %v: %w: distinct by needs a key", args, ErrParserNothingFound)
+	}
+	return result, remain, nil
+}
+
+// ParseExtend parses the columns to add with `extend`, which are the same as `into table`'s.
+func ParseExtend(args []string) (ast.Operation, []string, error) {
+	t, remain, err := ParseIntoTable(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extend: %w", err)
+	}
+	return &ast.ExtendTransformer{
+		Columns: t.(*ast.TableTransformer).Columns,
+	}, remain, nil
+}
+
+// ParseDrop parses the fields to drop, eg: `drop c.Notes h.Received`.
+func ParseDrop(args []string) (ast.Operation, []string, error) {
+	tks, remain, err := IntoTokenizerScan(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("drop: %w", err)
+	}
+	result := &ast.DropTransformer{}
+	for _, tkn := range tks {
+		e, ok := tkn.(ast.EntryExpression)
+		if !ok {
+			return nil, nil, fmt.Errorf("at %v: %w: drop needs fields such as c.name", tks, ErrUnexpectedToken)
+		}
+		result.Columns = append(result.Columns, entryName(e))
+	}
+	if len(result.Columns) == 0 {
+		return nil, nil, fmt.Errorf("at %v: %w", args, ErrParserNothingFound)
+	}
+	return result, remain, nil
+}
+
+// ParseRename parses pairs of a field and its new name, eg: `rename c.a .b h.Subject "Mail subject"`.
+func ParseRename(args []string) (ast.Operation, []string, error) {
+	tks, remain, err := IntoTokenizerScan(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rename: %w", err)
+	}
+	result := &ast.RenameTransformer{}
+	for len(tks) > 0 {
+		from, ok := tks[0].(ast.EntryExpression)
+		if !ok || len(tks) < 2 {
+			return nil, nil, fmt.Errorf("at %v: %w: rename needs a field and a .name", tks, ErrUnexpectedToken)
+		}
+		to, ok := tks[1].(ast.ConstantExpression)
+		if !ok {
+			return nil, nil, fmt.Errorf("at %v: %w: rename needs a field and a .name", tks, ErrUnexpectedToken)
+		}
+		for _, r := range result.Renames {
+			if strings.EqualFold(r.To, string(to)) {
+				return nil, nil, fmt.Errorf("rename %s: %w: %s", entryName(from), ast.ErrColumnExists, to)
+			}
+		}
+		result.Renames = append(result.Renames, ast.Rename{From: entryName(from), To: string(to)})
+		tks = tks[2:]
+	}
+	if len(result.Renames) == 0 {
+		return nil, nil, fmt.Errorf("at %v: %w", args, ErrParserNothingFound)
+	}
+	return result, remain, nil
+}
+
+// entryName is the field name of e without its prefix.
+func entryName(e ast.EntryExpression) string {
+	_, name, _ := strings.Cut(string(e), ".")
+	return name
 }
 
 func TokenMatcher(inputTokens []any, matchTokens ...any) []any {
@@ -420,18 +848,18 @@ func TokenMatcher(inputTokens []any, matchTokens ...any) []any {
 	return result
 }
 
-func ParseFilters(args []string) (ast.Operation, []string, error) {
+func ParseFilters(args []string, ops ...any) (ast.Operation, []string, error) {
 	result := &ast.CompoundStatement{}
 	p := args
 	for len(p) > 0 {
 		switch p[0] {
-		case "into":
+		case "into", "sort", "limit", "offset", "top", "distinct", "having", "extend", "drop", "rename":
 			return result.Simplify(), p, nil
 		case "filter", "where":
 			p = p[1:]
 			fallthrough
 		default:
-			boolExp, remain, err := ParseFilter(p[:], result.Statements)
+			boolExp, remain, err := ParseFilter(p[:], result.Statements, ops...)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -445,7 +873,7 @@ func ParseFilters(args []string) (ast.Operation, []string, error) {
 	return result.Simplify(), p, nil
 }
 
-func ParseInto(args []string) (ast.Operation, []string, error) {
+func ParseInto(args []string, ops ...any) (ast.Operation, []string, error) {
 	p := args
 	if len(p) > 0 {
 		switch p[0] {
@@ -458,7 +886,7 @@ func ParseInto(args []string) (ast.Operation, []string, error) {
 		case "mbox":
 			return &maildata.MBoxOutput{}, p[1:], nil
 		case "summary":
-			return ParseIntoSummary(p[1:])
+			return ParseIntoSummary(p[1:], ops...)
 		case "table":
 			return ParseIntoTable(p[1:])
 		}
@@ -466,14 +894,14 @@ func ParseInto(args []string) (ast.Operation, []string, error) {
 	return nil, nil, ErrUnknownIntoStatement
 }
 
-func ParseOperations(args []string) (ast.Operation, error) {
+func ParseOperations(args []string, ops ...any) (ast.Operation, error) {
 	result := &ast.CompoundStatement{}
 	p := args
 	for len(p) > 0 {
 		l := len(p)
 		switch p[0] {
 		case "filter":
-			op, remain, err := ParseFilters(p[1:])
+			op, remain, err := ParseFilters(p[1:], ops...)
 			if err != nil {
 				return nil, fmt.Errorf("parse filters: %w", err)
 			}
@@ -482,7 +910,7 @@ func ParseOperations(args []string) (ast.Operation, error) {
 				result.Statements = append(result.Statements, op)
 			}
 		case "into":
-			op, remain, err := ParseInto(p[1:])
+			op, remain, err := ParseInto(p[1:], ops...)
 			if err != nil {
 				return nil, fmt.Errorf("parse into: %w", err)
 			}
@@ -499,6 +927,57 @@ func ParseOperations(args []string) (ast.Operation, error) {
 			if op != nil {
 				result.Statements = append(result.Statements, op)
 			}
+		case "limit":
+			n, remain, err := ParseLimit(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse limit: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, &ast.LimitTransformer{N: n})
+		case "offset":
+			n, remain, err := ParseLimit(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse offset: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, &ast.OffsetTransformer{N: n})
+		case "top":
+			op, remain, err := ParseTop(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse top: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, op)
+		case "having":
+			return nil, fmt.Errorf("%w: having must follow into summary", ErrUnknownExpression)
+		case "extend":
+			op, remain, err := ParseExtend(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse extend: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, op)
+		case "drop":
+			op, remain, err := ParseDrop(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse drop: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, op)
+		case "rename":
+			op, remain, err := ParseRename(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse rename: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, op)
+		case "distinct":
+			op, remain, err := ParseDistinct(p[1:])
+			if err != nil {
+				return nil, fmt.Errorf("parse distinct: %w", err)
+			}
+			p = remain
+			result.Statements = append(result.Statements, op)
 		default:
 			return nil, fmt.Errorf("%w: %s", ErrUnknownExpression, p[0])
 		}
@@ -1,11 +1,17 @@
 package basic
 
 import (
+	"errors"
+	iofs "io/fs"
+	"pimtrace"
 	"pimtrace/ast"
 	"pimtrace/dataformats/maildata"
+	"pimtrace/dataformats/tabledata"
+	"pimtrace/fsys/fsystest"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/arran4/go-evaluator"
 	"github.com/google/go-cmp/cmp"
@@ -233,7 +239,7 @@ func TestParseFilter(t *testing.T) {
 			expectedExpression: &evaluator.Query{
 				Expression: &evaluator.NotExpression{
 					Expression: evaluator.Query{
-						Expression: &evaluator.IsExpression{Field: "user-agent", Value: "Kmail"},
+						Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.user-agent"), RHS: ast.ConstantExpression("Kmail")},
 					},
 				},
 			},
@@ -241,6 +247,176 @@ func TestParseFilter(t *testing.T) {
 			remaining:  []string{},
 			wantErr:    false,
 		},
+		{
+			name: "And binds tighter than or",
+			args: strings.Split("h.a eq .1 or h.b eq .2 and h.c eq .3 into table", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &evaluator.OrExpression{Expressions: []evaluator.Query{
+					{Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.a"), RHS: ast.ConstantExpression("1")}},
+					{Expression: &evaluator.AndExpression{Expressions: []evaluator.Query{
+						{Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.b"), RHS: ast.ConstantExpression("2")}},
+						{Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.c"), RHS: ast.ConstantExpression("3")}},
+					}}},
+				}},
+			},
+			remaining: []string{"into", "table"},
+		},
+		{
+			name: "Grouping and not",
+			args: strings.Split("( h.From contains .alice or h.From contains .bob ) and not h.Subject icontains .newsletter", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &evaluator.AndExpression{Expressions: []evaluator.Query{
+					{Expression: &evaluator.OrExpression{Expressions: []evaluator.Query{
+						{Expression: &evaluator.ContainsExpression{Field: "From", Value: "alice"}},
+						{Expression: &evaluator.ContainsExpression{Field: "From", Value: "bob"}},
+					}}},
+					{Expression: &evaluator.NotExpression{Expression: evaluator.Query{
+						Expression: &evaluator.IContainsExpression{Field: "Subject", Value: "newsletter"},
+					}}},
+				}},
+			},
+			remaining: []string{},
+		},
+		{
+			name: "Not applies to a group",
+			args: strings.Split("not ( h.a eq .1 or h.b eq .2 )", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &evaluator.NotExpression{Expression: evaluator.Query{
+					Expression: &evaluator.OrExpression{Expressions: []evaluator.Query{
+						{Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.a"), RHS: ast.ConstantExpression("1")}},
+						{Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.b"), RHS: ast.ConstantExpression("2")}},
+					}},
+				}},
+			},
+			remaining: []string{},
+		},
+		{
+			name: "Range operator",
+			args: strings.Split("c.Amount gt .100 into table", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &ast.Op{Op: "gt", LHS: ast.EntryExpression("c.Amount"), RHS: ast.ConstantExpression("100")},
+			},
+			remaining: []string{"into", "table"},
+		},
+		{
+			name: "Names the evaluator can't look up aren't shortened",
+			args: strings.Split("c.col_1 eq .x and p.SUMMARY contains .y", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &evaluator.AndExpression{Expressions: []evaluator.Query{
+					{Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("c.col_1"), RHS: ast.ConstantExpression("x")}},
+					{Expression: &ast.Op{Op: "contains", LHS: ast.EntryExpression("p.SUMMARY"), RHS: ast.ConstantExpression("y")}},
+				}},
+			},
+			remaining: []string{},
+		},
+		{
+			name: "Between",
+			args: strings.Split("p.DTSTART between .2024-01-01 .2024-12-31 and c.a ne .b", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &evaluator.AndExpression{Expressions: []evaluator.Query{
+					{Expression: &ast.Between{
+						Value: ast.EntryExpression("p.DTSTART"),
+						Low:   ast.ConstantExpression("2024-01-01"),
+						High:  ast.ConstantExpression("2024-12-31"),
+					}},
+					{Expression: &ast.Op{Op: "ne", LHS: ast.EntryExpression("c.a"), RHS: ast.ConstantExpression("b")}},
+				}},
+			},
+			remaining: []string{},
+		},
+		{
+			name: "In a list",
+			args: strings.Split("h.From in .a@x.com,.b@y.com", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: ast.NewIn(ast.EntryExpression("h.From"), []string{"a@x.com", "b@y.com"}),
+			},
+			remaining: []string{},
+		},
+		{
+			name: "Not in a list",
+			args: strings.Split("h.From not in .a@x.com into table", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &evaluator.NotExpression{Expression: evaluator.Query{
+					Expression: ast.NewIn(ast.EntryExpression("h.From"), []string{"a@x.com"}),
+				}},
+			},
+			remaining: []string{"into", "table"},
+		},
+		{
+			name: "In a list with a quoted item holding a comma",
+			args: []string{"h.Subject", "in", `"hello,`, `world",.bye`, "into", "table"},
+			expectedExpression: &evaluator.Query{
+				Expression: ast.NewIn(ast.EntryExpression("h.Subject"), []string{"hello, world", "bye"}),
+			},
+			remaining: []string{"into", "table"},
+		},
+		{
+			name:    "In a list with an item that isn't a constant",
+			args:    strings.Split("h.From in .a,h.To", " "),
+			wantErr: true,
+		},
+		{
+			name:    "In without a list",
+			args:    strings.Split("h.From in", " "),
+			wantErr: true,
+		},
+		{
+			name:    "Not without in",
+			args:    strings.Split("h.From not eq .a@x.com", " "),
+			wantErr: true,
+		},
+		{
+			name: "Function on the left",
+			args: strings.Split("f.year[h.Date] ge .2020", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &ast.Op{Op: "ge", LHS: year("h.Date"), RHS: ast.ConstantExpression("2020")},
+			},
+			remaining: []string{},
+		},
+		{
+			name: "Nested function compared with a constant on the left",
+			args: strings.Split(".true eq f.eq[f.year[h.Date],.2024]", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &ast.Op{Op: "eq", LHS: ast.ConstantExpression("true"), RHS: &ast.FunctionExpression{
+					Function: "eq",
+					Args:     []ast.ValueExpression{year("h.Date"), ast.ConstantExpression("2024")},
+				}},
+			},
+			remaining: []string{},
+		},
+		{
+			name: "Constant on the left keeps operand order",
+			args: strings.Split(".alice-and-bob contains h.From", " "),
+			expectedExpression: &evaluator.Query{
+				Expression: &ast.Op{Op: "contains", LHS: ast.ConstantExpression("alice-and-bob"), RHS: ast.EntryExpression("h.From")},
+			},
+			remaining: []string{},
+		},
+		{
+			name:    "Invalid function",
+			args:    strings.Split("f.year[h.Date eq .2020", " "),
+			wantErr: true,
+		},
+		{
+			name:    "Between without a high value",
+			args:    strings.Split("c.Amount between .1", " "),
+			wantErr: true,
+		},
+		{
+			name:    "Missing closing parenthesis",
+			args:    strings.Split("( h.a eq .1 or h.b eq .2", " "),
+			wantErr: true,
+		},
+		{
+			name:    "Unexpected closing parenthesis",
+			args:    strings.Split("h.a eq .1 and ) h.b eq .2", " "),
+			wantErr: true,
+		},
+		{
+			name:    "Dangling or",
+			args:    strings.Split("h.a eq .1 or", " "),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -263,6 +439,82 @@ func TestParseFilter(t *testing.T) {
 	}
 }
 
+func year(arg string) *ast.EvaluatorFunctionExpression {
+	return &ast.EvaluatorFunctionExpression{
+		Function: "year",
+		FunctionExpression: evaluator.FunctionExpression{
+			Name: "year",
+			Args: []evaluator.Term{ast.EntryExpression(arg)},
+		},
+	}
+}
+
+func TestParseFilter_Patterns(t *testing.T) {
+	got, remain, err := ParseFilter(strings.Split(`h.Message-Id matches .^<.*@example\.com>$ into table`, " "), nil)
+	if err != nil {
+		t.Fatalf("ParseFilter() error = %v", err)
+	}
+	m, ok := got.Expression.(*ast.Match)
+	if !ok {
+		t.Fatalf("ParseFilter() = %T, want a compiled *ast.Match", got.Expression)
+	}
+	if m.Value != ast.EntryExpression("h.Message-Id") || m.Pattern.String() != `^<.*@example\.com>$` {
+		t.Errorf("ParseFilter() = %v %v", m.Value, m.Pattern)
+	}
+	if diff := cmp.Diff(remain, []string{"into", "table"}); diff != "" {
+		t.Errorf("ParseFilter() remaining %s", diff)
+	}
+	if _, _, err := ParseFilter([]string{"h.Subject", "matches", ".("}, nil); !errors.Is(err, ast.ErrInvalidPattern) {
+		t.Errorf("ParseFilter() error = %v, want %v", err, ast.ErrInvalidPattern)
+	}
+}
+
+func TestParseFilter_EqualComplementsNotEqual(t *testing.T) {
+	headers := map[string]int{"amount": 0}
+	count := func(query string) int {
+		q, _, err := ParseFilter(strings.Split(query, " "), nil)
+		if err != nil {
+			t.Fatalf("ParseFilter(%s) error = %v", query, err)
+		}
+		data := tabledata.Data{
+			{Headers: headers, Row: []pimtrace.Value{pimtrace.SimpleStringValue("150.0")}},
+			{Headers: headers, Row: []pimtrace.Value{pimtrace.SimpleFloatValue(150)}},
+			{Headers: headers, Row: []pimtrace.Value{pimtrace.SimpleStringValue("151")}},
+		}
+		d, err := (&ast.FilterStatement{Expression: q}).Execute(data, &evaluator.Context{})
+		if err != nil {
+			t.Fatalf("Execute(%s) error = %v", query, err)
+		}
+		return d.Len()
+	}
+	eq, ne := count("c.amount eq .150"), count("c.amount ne .150")
+	if eq != 2 || ne != 1 {
+		t.Errorf("eq kept %d and ne kept %d, want 2 and 1", eq, ne)
+	}
+	if swapped := count(".150 eq c.amount"); swapped != eq {
+		t.Errorf("swapped eq kept %d, want %d", swapped, eq)
+	}
+}
+
+func TestParseFilter_InFile(t *testing.T) {
+	fs := fsystest.MapFSAdapter{MapFS: fstest.MapFS{
+		"senders.txt": {Data: []byte("a@x.com\n\n  b@y.com \n")},
+	}}
+	got, _, err := ParseFilter(strings.Split("h.From in @senders.txt", " "), nil, fs)
+	if err != nil {
+		t.Fatalf("ParseFilter() error = %v", err)
+	}
+	want := &evaluator.Query{
+		Expression: ast.NewIn(ast.EntryExpression("h.From"), []string{"a@x.com", "b@y.com"}),
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("ParseFilter() %s", diff)
+	}
+	if _, _, err := ParseFilter(strings.Split("h.From in @missing.txt", " "), nil, fs); !errors.Is(err, iofs.ErrNotExist) {
+		t.Errorf("ParseFilter() error = %v, want %v", err, iofs.ErrNotExist)
+	}
+}
+
 func TestParseOperations(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -285,7 +537,7 @@ func TestParseOperations(t *testing.T) {
 				Expression: &evaluator.Query{
 					Expression: &evaluator.NotExpression{
 						Expression: evaluator.Query{
-							Expression: &evaluator.IsExpression{Field: "user-agent", Value: "Kmail"},
+							Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.user-agent"), RHS: ast.ConstantExpression("Kmail")},
 						},
 					},
 				},
@@ -311,6 +563,111 @@ func TestParseOperations(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "filter then offset, limit and top",
+			args: strings.Split("filter h.From eq .bob offset 5 limit 10 top 3 by c.count asc c.From", " "),
+			expectedOperation: &ast.CompoundStatement{
+				Statements: []ast.Operation{
+					&ast.FilterStatement{
+						Expression: &evaluator.Query{
+							Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.From"), RHS: ast.ConstantExpression("bob")},
+						},
+					},
+					&ast.OffsetTransformer{N: 5},
+					&ast.LimitTransformer{N: 10},
+					&ast.TopTransformer{N: 3, Sort: &ast.SortTransformer{
+						Expression: []ast.ValueExpression{ast.EntryExpression("c.count"), ast.EntryExpression("c.From")},
+						Order:      []ast.SortOrder{{Descending: true}, {}},
+					}},
+				},
+			},
+		},
+		{
+			name: "filter then distinct by then distinct",
+			args: strings.Split("filter h.From eq .bob distinct by h.Message-Id h.Subject distinct into mbox", " "),
+			expectedOperation: &ast.CompoundStatement{
+				Statements: []ast.Operation{
+					&ast.FilterStatement{
+						Expression: &evaluator.Query{
+							Expression: &ast.Op{Op: "eq", LHS: ast.EntryExpression("h.From"), RHS: ast.ConstantExpression("bob")},
+						},
+					},
+					&ast.DistinctTransformer{Expression: []ast.ValueExpression{
+						ast.EntryExpression("h.Message-Id"),
+						ast.EntryExpression("h.Subject"),
+					}},
+					&ast.DistinctTransformer{},
+					&maildata.MBoxOutput{},
+				},
+			},
+		},
+		{
+			name:    "having without a summary",
+			args:    strings.Split("filter c.a eq .1 having c.count gt .1", " "),
+			wantErr: true,
+		},
+		{
+			name: "extend, drop and rename",
+			args: strings.Split(`extend f.as[f.year[c.Date],.Year] c.Amount drop c.Notes h.Received rename c.Amount .Total c.Date "Paid on" sort c.Year`, " "),
+			expectedOperation: &ast.CompoundStatement{
+				Statements: []ast.Operation{
+					&ast.ExtendTransformer{Columns: []*ast.ColumnExpression{
+						{
+							Name: "Year",
+							Operation: &ast.EvaluatorFunctionExpression{
+								Function: "as",
+								FunctionExpression: evaluator.FunctionExpression{Name: "as", Args: []evaluator.Term{
+									&ast.EvaluatorFunctionExpression{
+										Function:           "year",
+										FunctionExpression: evaluator.FunctionExpression{Name: "year", Args: []evaluator.Term{ast.EntryExpression("c.Date")}},
+									},
+									ast.ConstantExpression("Year"),
+								}},
+							},
+						},
+						{Name: "Amount", Operation: ast.EntryExpression("c.Amount")},
+					}},
+					&ast.DropTransformer{Columns: []string{"Notes", "Received"}},
+					&ast.RenameTransformer{Renames: []ast.Rename{{From: "Amount", To: "Total"}, {From: "Date", To: "Paid on"}}},
+					&ast.SortTransformer{Expression: []ast.ValueExpression{ast.EntryExpression("c.Year")}},
+				},
+			},
+		},
+		{
+			name:    "rename needs a new name",
+			args:    []string{"rename", "c.a", "c.b"},
+			wantErr: true,
+		},
+		{
+			name:    "rename two fields to the same name",
+			args:    []string{"rename", "c.a", ".x", "c.b", ".X"},
+			wantErr: true,
+		},
+		{
+			name:    "drop needs fields",
+			args:    []string{"drop", ".a"},
+			wantErr: true,
+		},
+		{
+			name:    "distinct by needs a key",
+			args:    []string{"distinct", "by", "into", "mbox"},
+			wantErr: true,
+		},
+		{
+			name:    "limit needs a count",
+			args:    []string{"limit", "ten"},
+			wantErr: true,
+		},
+		{
+			name:    "limit can't be negative",
+			args:    []string{"limit", "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "top needs by",
+			args:    []string{"top", "3", "c.count"},
+			wantErr: true,
+		},
 		// {
 		// 	name: "filter into a table",
 		// 	// Skipped due to fragility in comparison of FunctionExpression.F
@@ -368,6 +725,50 @@ func TestParseSort(t *testing.T) {
 			remaining: []string{"into", "mbox"},
 			wantErr:   false,
 		},
+		{
+			name: "Mixed directions",
+			args: []string{"desc", "c.count", "asc", "c.name", "into", "mbox"},
+			expectedOperation: &ast.SortTransformer{
+				Expression: []ast.ValueExpression{
+					ast.EntryExpression("c.count"),
+					ast.EntryExpression("c.name"),
+				},
+				Order: []ast.SortOrder{{Descending: true}, {}},
+			},
+			remaining: []string{"into", "mbox"},
+		},
+		{
+			name: "Minus prefix and nulls",
+			args: []string{"-c.count", "nulls", "last", "c.name", "nulls", "first"},
+			expectedOperation: &ast.SortTransformer{
+				Expression: []ast.ValueExpression{
+					ast.EntryExpression("c.count"),
+					ast.EntryExpression("c.name"),
+				},
+				Order: []ast.SortOrder{{Descending: true, Nulls: ast.NullsLast}, {Nulls: ast.NullsFirst}},
+			},
+			remaining: []string{},
+		},
+		{
+			name:    "Dangling direction",
+			args:    []string{"c.name", "desc"},
+			wantErr: true,
+		},
+		{
+			name:    "Nulls without position",
+			args:    []string{"c.name", "nulls", "middle"},
+			wantErr: true,
+		},
+		{
+			name:    "Nulls without key",
+			args:    []string{"nulls", "first", "c.name"},
+			wantErr: true,
+		},
+		{
+			name:    "No keys",
+			args:    []string{"into", "mbox"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -464,6 +865,39 @@ func TestParseIntoSummary(t *testing.T) {
 			remaining: []string{"into", "mbox"},
 			wantErr:   false,
 		},
+		{
+			name: "Summary having a calculated column",
+			args: strings.Split("c.cat calculate f.count having c.count gt .5 or f.sum[c.pay] gt .10 sort c.cat", " "),
+			expectedOperation: &ast.CompoundStatement{
+				Statements: []ast.Operation{
+					&ast.GroupTransformer{
+						Columns: []*ast.ColumnExpression{
+							{Operation: ast.EntryExpression("c.cat"), Name: "cat"},
+						},
+					},
+					&ast.FilterStatement{
+						Expression: &evaluator.Query{
+							Expression: &evaluator.OrExpression{Expressions: []evaluator.Query{
+								{Expression: &ast.Op{Op: "gt", LHS: &ast.FunctionExpression{Function: "count"}, RHS: ast.ConstantExpression("5")}},
+								{Expression: &ast.Op{Op: "gt", LHS: &ast.FunctionExpression{Function: "sum", Args: []ast.ValueExpression{ast.EntryExpression("c.pay")}}, RHS: ast.ConstantExpression("10")}},
+							}},
+						},
+					},
+					&ast.TableTransformer{
+						Columns: []*ast.ColumnExpression{
+							{Operation: ast.EntryExpression("c.cat"), Name: "cat"},
+							{Operation: &ast.FunctionExpression{Function: "count"}, Name: "count"},
+						},
+					},
+				},
+			},
+			remaining: []string{"sort", "c.cat"},
+		},
+		{
+			name:    "Summary having without a condition",
+			args:    []string{"c.cat", "calculate", "f.count", "having"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -521,6 +955,7 @@ func TestParseFunctionExpression(t *testing.T) {
 			want: &ast.EvaluatorFunctionExpression{
 				Function: "year",
 				FunctionExpression: evaluator.FunctionExpression{
+					Name: "year",
 					Args: []evaluator.Term{
 						ast.EntryExpression("c.name"),
 					},
@@ -535,6 +970,7 @@ func TestParseFunctionExpression(t *testing.T) {
 			want: &ast.EvaluatorFunctionExpression{
 				Function: "year",
 				FunctionExpression: evaluator.FunctionExpression{
+					Name: "year",
 					Args: []evaluator.Term{
 						ast.EntryExpression("c.name"),
 						ast.EntryExpression("c.date"),
@@ -544,6 +980,24 @@ func TestParseFunctionExpression(t *testing.T) {
 			remaining: []string{},
 			wantErr:   false,
 		},
+		{
+			name: "Function split over arguments",
+			args: []string{"f.contains[h.Subject,", `"Hello,`, `world"]`, "c.name"},
+			want: &ast.FunctionExpression{
+				Function: "contains",
+				Args: []ast.ValueExpression{
+					ast.EntryExpression("h.Subject"),
+					ast.ConstantExpression("Hello, world"),
+				},
+			},
+			remaining: []string{"c.name"},
+			wantErr:   false,
+		},
+		{
+			name:    "Unbalanced brackets",
+			args:    []string{"f.count[c.name"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -631,6 +1085,7 @@ func TestParseExpressions_MoreParams(t *testing.T) {
 				&ast.EvaluatorFunctionExpression{
 					Function: "year",
 					FunctionExpression: evaluator.FunctionExpression{
+						Name: "year",
 						Args: []evaluator.Term{
 							ast.EntryExpression("c.name"),
 						},
@@ -639,6 +1094,27 @@ func TestParseExpressions_MoreParams(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Nested function params",
+			s:    "f.or[f.contains[h.Subject,.urgent],f.eq[h.From,.boss]],.x",
+			want: []ast.ValueExpression{
+				&ast.FunctionExpression{
+					Function: "or",
+					Args: []ast.ValueExpression{
+						&ast.FunctionExpression{
+							Function: "contains",
+							Args:     []ast.ValueExpression{ast.EntryExpression("h.Subject"), ast.ConstantExpression("urgent")},
+						},
+						&ast.FunctionExpression{
+							Function: "eq",
+							Args:     []ast.ValueExpression{ast.EntryExpression("h.From"), ast.ConstantExpression("boss")},
+						},
+					},
+				},
+				ast.ConstantExpression("x"),
+			},
+			wantErr: false,
+		},
 		{
 			name:    "Unknown param",
 			s:       "unknown",
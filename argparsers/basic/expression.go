@@ -0,0 +1,196 @@
+package basic
+
+import (
+	"fmt"
+	"pimtrace/ast"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+)
+
+// expressionParser is a recursive descent parser over the tokens of a single expression:
+//
+//	value     = string | constant | entry | function
+//	function  = ("f." | "func.") name [ "[" [ value { "," value } ] "]" ]
+type expressionParser struct {
+	expression string
+	tokens     []Token
+	pos        int
+}
+
+func newExpressionParser(s string, depth int) (*expressionParser, error) {
+	tokens, err := lex(s, depth)
+	if err != nil {
+		return nil, err
+	}
+	return &expressionParser{expression: s, tokens: tokens}, nil
+}
+
+// ParseValueExpression parses a single value such as `h.From`, `.value`, `"quoted value"` or `f.as[f.year[h.Date],.Year]`.
+func ParseValueExpression(s string) (ast.ValueExpression, error) {
+	p, err := newExpressionParser(s, 0)
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(TokenEOF); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ParseExpressions parses a comma separated list of function arguments.
+func ParseExpressions(s string) ([]ast.ValueExpression, error) {
+	p, err := newExpressionParser(s, 1)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().Kind == TokenEOF {
+		return nil, nil
+	}
+	return p.values(TokenEOF)
+}
+
+// SplitArguments splits function arguments on the commas which aren't inside a nested function's brackets or a quoted
+// string. Arguments which can't be lexed are returned whole.
+func SplitArguments(s string) []string {
+	tokens, err := lex(s, 1)
+	if err != nil {
+		return []string{s}
+	}
+	var result []string
+	depth := 0
+	start := 0
+	for _, t := range tokens {
+		switch t.Kind {
+		case TokenOpen:
+			depth++
+		case TokenClose:
+			depth--
+		case TokenComma:
+			if depth == 0 {
+				result = append(result, s[start:t.Pos])
+				start = t.Pos + 1
+			}
+		}
+	}
+	return append(result, s[start:])
+}
+
+func (p *expressionParser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() Token {
+	t := p.tokens[p.pos]
+	if t.Kind != TokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *expressionParser) errorAt(t Token, err error) error {
+	return &SyntaxError{Expression: p.expression, Pos: t.Pos, Err: err}
+}
+
+func (p *expressionParser) expect(kind TokenKind) error {
+	if t := p.next(); t.Kind != kind {
+		return p.errorAt(t, fmt.Errorf("%w: %s, expected %s", ErrUnexpectedToken, t, Token{Kind: kind, Text: tokenText[kind]}))
+	}
+	return nil
+}
+
+var tokenText = map[TokenKind]string{
+	TokenOpen:  "[",
+	TokenClose: "]",
+	TokenComma: ",",
+}
+
+// values parses values separated by commas up to end.
+func (p *expressionParser) values(end TokenKind) ([]ast.ValueExpression, error) {
+	var result []ast.ValueExpression
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+		switch t := p.next(); t.Kind {
+		case TokenComma:
+		case end:
+			return result, nil
+		default:
+			return nil, p.errorAt(t, fmt.Errorf("%w: %s, expected , or %s", ErrUnexpectedToken, t, Token{Kind: end, Text: tokenText[end]}))
+		}
+	}
+}
+
+func (p *expressionParser) value() (ast.ValueExpression, error) {
+	t := p.next()
+	switch t.Kind {
+	case TokenString:
+		return ast.ConstantExpression(t.Text), nil
+	case TokenWord:
+	default:
+		return nil, p.errorAt(t, fmt.Errorf("%w: %s, expected a value", ErrUnexpectedToken, t))
+	}
+	ss := strings.SplitN(t.Text, ".", 2)
+	if len(ss) < 2 {
+		return nil, p.errorAt(t, fmt.Errorf("%w: %s", ErrParserUnknownToken, t.Text))
+	}
+	switch ss[0] {
+	case "h", "header", "c", "column", "p", "property":
+		return ast.EntryExpression(t.Text), nil
+	case "f", "func":
+		return p.function(t, ss[1])
+	case "":
+		return ast.ConstantExpression(ss[1]), nil
+	}
+	return nil, p.errorAt(t, fmt.Errorf("%w: %s", ErrParserUnknownToken, ss[0]))
+}
+
+func (p *expressionParser) function(t Token, name string) (ast.ValueExpression, error) {
+	if name == "" {
+		return nil, p.errorAt(t, fmt.Errorf("%w: %s has no function name", ErrUnexpectedToken, t))
+	}
+	var params []ast.ValueExpression
+	if p.peek().Kind == TokenOpen {
+		p.next()
+		if p.peek().Kind == TokenClose {
+			p.next()
+		} else {
+			var err error
+			if params, err = p.values(TokenClose); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return newFunctionExpression(name, params), nil
+}
+
+func newFunctionExpression(name string, params []ast.ValueExpression) ast.ValueExpression {
+	// Check if it is an Evaluator Function (standard list or heuristic)
+	// We hardcode known evaluator functions here or rely on runtime resolution.
+	if isEvaluatorFunction(name) {
+		// Convert params to []evaluator.Term
+		var terms []evaluator.Term
+		for _, p := range params {
+			terms = append(terms, p)
+		}
+		return &ast.EvaluatorFunctionExpression{
+			Function: name,
+			FunctionExpression: evaluator.FunctionExpression{
+				// Func is resolved at runtime via Context by Name
+				Name: name,
+				Args: terms,
+			},
+		}
+	}
+	return &ast.FunctionExpression{
+		Function: name,
+		Args:     params,
+	}
+}
@@ -0,0 +1,92 @@
+package basic
+
+import (
+	"errors"
+	"pimtrace/ast"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseValueExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		want       ast.ValueExpression
+		wantErr    error
+		wantColumn int
+	}{
+		{name: "Entry", s: "h.Message-Id", want: ast.EntryExpression("h.Message-Id")},
+		{name: "Constant", s: ".Hello, world", want: ast.ConstantExpression("Hello, world")},
+		{name: "Quoted", s: `"Hello, world"`, want: ast.ConstantExpression("Hello, world")},
+		{
+			name: "Nested with quoted arguments",
+			s:    `f.or[ f.contains[h.Subject, "a, b"], f.eq[ h.From , 'x]' ] ]`,
+			want: &ast.FunctionExpression{
+				Function: "or",
+				Args: []ast.ValueExpression{
+					&ast.FunctionExpression{Function: "contains", Args: []ast.ValueExpression{ast.EntryExpression("h.Subject"), ast.ConstantExpression("a, b")}},
+					&ast.FunctionExpression{Function: "eq", Args: []ast.ValueExpression{ast.EntryExpression("h.From"), ast.ConstantExpression("x]")}},
+				},
+			},
+		},
+		{name: "Empty arguments", s: "f.count[]", want: &ast.FunctionExpression{Function: "count"}},
+		{name: "Missing close", s: "f.sum[c.a", wantErr: ErrUnexpectedToken, wantColumn: 10},
+		{name: "Missing comma", s: `f.sum["a" c.b]`, wantErr: ErrUnexpectedToken, wantColumn: 11},
+		{name: "Trailing text", s: "f.count[] c.a", wantErr: ErrUnexpectedToken, wantColumn: 11},
+		{name: "Unknown argument", s: "f.sum[x.a]", wantErr: ErrParserUnknownToken, wantColumn: 7},
+		{name: "Unterminated string", s: `f.sum["a]`, wantErr: ErrUnterminatedString, wantColumn: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValueExpression(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseValueExpression() error = %v, want %v", err, tt.wantErr)
+			}
+			var se *SyntaxError
+			if errors.As(err, &se) && se.Column() != tt.wantColumn {
+				t.Errorf("ParseValueExpression() error column = %d, want %d", se.Column(), tt.wantColumn)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("ParseValueExpression() %s", diff)
+			}
+		})
+	}
+}
+
+func TestSplitArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{name: "Simple", s: "h.a,.b", want: []string{"h.a", ".b"}},
+		{name: "Nested function", s: "f.eq[h.a,.b], .c", want: []string{"f.eq[h.a,.b]", " .c"}},
+		{name: "Quoted comma", s: `"a, b",.c`, want: []string{`"a, b"`, ".c"}},
+		{name: "Unterminated string", s: `"a, b`, want: []string{`"a, b`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(SplitArguments(tt.s), tt.want); diff != "" {
+				t.Errorf("SplitArguments() %s", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionParameterExpressionIdentify(t *testing.T) {
+	got, remain, err := FunctionParameterExpressionIdentify([]string{"f.contains[h.Subject,", ".Title]", "into"})
+	if err != nil {
+		t.Fatalf("FunctionParameterExpressionIdentify() error = %v", err)
+	}
+	want := &ast.FunctionExpression{Function: "contains", Args: []ast.ValueExpression{ast.EntryExpression("h.Subject"), ast.ConstantExpression("Title")}}
+	if diff := cmp.Diff(got, ast.ValueExpression(want)); diff != "" {
+		t.Errorf("FunctionParameterExpressionIdentify() %s", diff)
+	}
+	if diff := cmp.Diff(remain, []string{"into"}); diff != "" {
+		t.Errorf("FunctionParameterExpressionIdentify() remain %s", diff)
+	}
+	if _, _, err := FunctionParameterExpressionIdentify([]string{"into"}); !errors.Is(err, ErrParserUnknownToken) {
+		t.Errorf("FunctionParameterExpressionIdentify() error = %v, want %v", err, ErrParserUnknownToken)
+	}
+}
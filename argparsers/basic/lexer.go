@@ -0,0 +1,217 @@
+package basic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnexpectedToken    = fmt.Errorf("unexpected token")
+	ErrUnterminatedString = fmt.Errorf("unterminated string")
+)
+
+// SyntaxError is an error at a position in an expression.
+type SyntaxError struct {
+	Expression string
+	Pos        int
+	Err        error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d of %q", e.Err, e.Column(), e.Expression)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Column is the 1 based position of the error in characters.
+func (e *SyntaxError) Column() int {
+	if e.Pos > len(e.Expression) {
+		return utf8.RuneCountInString(e.Expression) + 1
+	}
+	return utf8.RuneCountInString(e.Expression[:e.Pos]) + 1
+}
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	// TokenWord is an entry such as `h.From`, a function name such as `f.year` or a constant such as `.value`
+	TokenWord
+	// TokenString is a quoted string, Text has the quotes removed and escapes replaced
+	TokenString
+	TokenOpen
+	TokenClose
+	TokenComma
+)
+
+type Token struct {
+	Kind TokenKind
+	Text string
+	// Pos is the byte offset of the token in the expression
+	Pos int
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case TokenEOF:
+		return "end of expression"
+	case TokenString:
+		return fmt.Sprintf("%q", t.Text)
+	}
+	return t.Text
+}
+
+// Lex splits an expression into tokens, the last of which is always TokenEOF.
+//
+// Outside of brackets a word which isn't a function name runs to the end of the expression, so a shell argument such
+// as `.hello, world` is still a single constant. Inside brackets words end at `,` or the closing `]`, and strings
+// quoted with `"` or `'` can hold anything, with `\` escaping the quote, `\`, `n`, `t` and `r`. Other escapes are kept as
+// written so patterns such as `"\d+\."` don't need their backslashes doubled.
+func Lex(s string) ([]Token, error) {
+	return lex(s, 0)
+}
+
+func lex(s string, depth int) ([]Token, error) {
+	var tokens []Token
+	i := 0
+	for {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return append(tokens, Token{Kind: TokenEOF, Pos: i}), nil
+		}
+		switch s[i] {
+		case '[':
+			depth++
+			tokens = append(tokens, Token{Kind: TokenOpen, Text: "[", Pos: i})
+			i++
+		case ']':
+			depth--
+			tokens = append(tokens, Token{Kind: TokenClose, Text: "]", Pos: i})
+			i++
+		case ',':
+			tokens = append(tokens, Token{Kind: TokenComma, Text: ",", Pos: i})
+			i++
+		case '"', '\'':
+			text, end, err := lexString(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, Token{Kind: TokenString, Text: text, Pos: i})
+			i = end
+		default:
+			end := lexWord(s, i, depth)
+			tokens = append(tokens, Token{Kind: TokenWord, Text: strings.TrimRight(s[i:end], " \t"), Pos: i})
+			i = end
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isFunctionWord(s string) bool {
+	return strings.HasPrefix(s, "f.") || strings.HasPrefix(s, "func.")
+}
+
+// lexWord returns the end of the word starting at i.
+func lexWord(s string, i int, depth int) int {
+	if isFunctionWord(s[i:]) {
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '[', ']', ',', ' ', '\t':
+				return i
+			}
+		}
+		return i
+	}
+	if depth <= 0 {
+		return len(s)
+	}
+	nested := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			nested++
+		case ']':
+			if nested == 0 {
+				return i
+			}
+			nested--
+		case ',':
+			if nested == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+func lexString(s string, start int) (string, int, error) {
+	quote := s[start]
+	var b strings.Builder
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, &SyntaxError{Expression: s, Pos: start, Err: ErrUnterminatedString}
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '\'':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, &SyntaxError{Expression: s, Pos: start, Err: ErrUnterminatedString}
+}
+
+// joinExpression joins a function call or quoted string which the shell split over several arguments, such as
+// `f.as[h.Subject,` `.Title]`, returning it and the remaining arguments.
+func joinExpression(args []string) (string, []string) {
+	s := args[0]
+	if !isFunctionWord(s) && !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, `'`) {
+		return s, args[1:]
+	}
+	n := 1
+	for ; n < len(args) && unbalanced(s); n++ {
+		s += " " + args[n]
+	}
+	return s, args[n:]
+}
+
+func unbalanced(s string) bool {
+	tokens, err := Lex(s)
+	if err != nil {
+		return errors.Is(err, ErrUnterminatedString)
+	}
+	depth := 0
+	for _, t := range tokens {
+		switch t.Kind {
+		case TokenOpen:
+			depth++
+		case TokenClose:
+			depth--
+		}
+	}
+	return depth > 0
+}
@@ -0,0 +1,99 @@
+package basic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []Token
+		wantErr error
+	}{
+		{
+			name: "Top level constant keeps commas and spaces",
+			s:    ".hello, world",
+			want: []Token{{Kind: TokenWord, Text: ".hello, world"}, {Kind: TokenEOF, Pos: 13}},
+		},
+		{
+			name: "Function with spaced arguments",
+			s:    `f.as[ h.Subject , "a, [b]" ]`,
+			want: []Token{
+				{Kind: TokenWord, Text: "f.as"},
+				{Kind: TokenOpen, Text: "[", Pos: 4},
+				{Kind: TokenWord, Text: "h.Subject", Pos: 6},
+				{Kind: TokenComma, Text: ",", Pos: 16},
+				{Kind: TokenString, Text: "a, [b]", Pos: 18},
+				{Kind: TokenClose, Text: "]", Pos: 27},
+				{Kind: TokenEOF, Pos: 28},
+			},
+		},
+		{
+			name: "Escapes",
+			s:    `'it\'s\t\\'`,
+			want: []Token{{Kind: TokenString, Text: "it's\t\\"}, {Kind: TokenEOF, Pos: 11}},
+		},
+		{
+			name: "Bracketed constant inside a function",
+			s:    "f.x[.a[1]]",
+			want: []Token{
+				{Kind: TokenWord, Text: "f.x"},
+				{Kind: TokenOpen, Text: "[", Pos: 3},
+				{Kind: TokenWord, Text: ".a[1]", Pos: 4},
+				{Kind: TokenClose, Text: "]", Pos: 9},
+				{Kind: TokenEOF, Pos: 10},
+			},
+		},
+		{
+			name:    "Unterminated string",
+			s:       `f.x["abc]`,
+			wantErr: ErrUnterminatedString,
+		},
+		{
+			name: "Unknown escapes are kept",
+			s:    `"\d+\.\q"`,
+			want: []Token{{Kind: TokenString, Text: `\d+\.\q`}, {Kind: TokenEOF, Pos: 9}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lex(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Lex() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("Lex() %s", diff)
+			}
+		})
+	}
+}
+
+func TestJoinExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		want   string
+		remain []string
+	}{
+		{name: "Single argument", args: []string{"f.count", "c.a"}, want: "f.count", remain: []string{"c.a"}},
+		{name: "Constant isn't joined", args: []string{".it's", "c.a"}, want: ".it's", remain: []string{"c.a"}},
+		{name: "Split function", args: []string{"f.as[h.Subject,", ".Title]", "c.a"}, want: "f.as[h.Subject, .Title]", remain: []string{"c.a"}},
+		{name: "Split string", args: []string{`"Hello,`, `world"`}, want: `"Hello, world"`, remain: []string{}},
+		{name: "Never closed", args: []string{"f.as[h.Subject,", ".Title"}, want: "f.as[h.Subject, .Title", remain: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, remain := joinExpression(tt.args)
+			if got != tt.want {
+				t.Errorf("joinExpression() = %q, want %q", got, tt.want)
+			}
+			if diff := cmp.Diff(remain, tt.remain); diff != "" {
+				t.Errorf("joinExpression() remaining %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package basic
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"pimtrace/fsys"
+	"strings"
+)
+
+var (
+	ErrQueryConflict = fmt.Errorf("conflicting query options")
+)
+
+// SplitQuery splits a query script into arguments the way a shell would split a query on the command line. Arguments
+// are separated by any white space including line breaks, and a `#` at the start of an argument comments out the rest
+// of the line. Quoted strings are kept whole, quotes included, for ParseValueExpression. A quote only starts a string
+// at the start of an argument or after a `[` or `,` so `.it's` is still a single constant.
+func SplitQuery(s string) ([]string, error) {
+	return splitQuery(s, 1, true)
+}
+
+// splitQuery is SplitQuery for a script starting at line of a larger file, without comments unless comments is set.
+func splitQuery(s string, line int, comments bool) ([]string, error) {
+	var result []string
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			result = append(result, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\n':
+			line++
+			flush()
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '#' && !inWord && comments:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			i--
+		case (c == '"' || c == '\'') && (!inWord || strings.ContainsRune("[,", rune(s[i-1]))):
+			end := quoteEnd(s, i)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: %w", line, ErrUnterminatedString)
+			}
+			line += strings.Count(s[i:end], "\n")
+			word.WriteString(s[i:end])
+			inWord = true
+			i = end - 1
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return result, nil
+}
+
+// quoteEnd returns the position after the quote closing the string starting at start, or -1.
+func quoteEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case s[start]:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// ReadQuery reads and splits a query script, see SplitQuery.
+func ReadQuery(r io.Reader) ([]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return SplitQuery(string(b))
+}
+
+// LoadQuery returns the query arguments given by the -query and -query-file flags, where query is the query itself or
+// `-` to read a query script from stdin and queryFile is the path of a query script. It returns nil if neither are set.
+// Only scripts have comments, a `#` in the query itself is kept as it would be on the command line.
+//
+// Options: fsys.FS used to read queryFile
+func LoadQuery(query string, queryFile string, stdin io.Reader, ops ...any) ([]string, error) {
+	switch {
+	case query != "" && queryFile != "":
+		return nil, fmt.Errorf("%w: use one of -query and -query-file", ErrQueryConflict)
+	case query == "-":
+		return ReadQuery(stdin)
+	case query != "":
+		return splitQuery(query, 1, false)
+	case queryFile != "":
+		fs := fsys.NewOSFS()
+		for _, op := range ops {
+			if o, ok := op.(fsys.FS); ok {
+				fs = o
+			}
+		}
+		f, err := fs.OpenFile(queryFile, os.O_RDONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("reading query %s: %w", queryFile, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		args, err := ReadQuery(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading query %s: %w", queryFile, err)
+		}
+		return args, nil
+	}
+	return nil, nil
+}
+
+// QueryArgs returns the query given by the -query, -query-file or -run flags, otherwise args, the query given as
+// arguments. inputFile is the -input flag, the query and the input can't both be read from stdin.
+//
+// Options: fsys.FS used to read queryFile and queriesFile
+func QueryArgs(args []string, query, queryFile, inputFile, run string, params Params, queriesFile string, stdin io.Reader, ops ...any) ([]string, error) {
+	if len(params) > 0 && run == "" {
+		return nil, fmt.Errorf("%w: -param is only used with -run", ErrQueryConflict)
+	}
+	if query == "" && queryFile == "" && run == "" {
+		return args, nil
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%w: query given by flag and as arguments", ErrQueryConflict)
+	}
+	if run != "" {
+		if query != "" || queryFile != "" {
+			return nil, fmt.Errorf("%w: use one of -query, -query-file and -run", ErrQueryConflict)
+		}
+		return RunSavedQuery(queriesFile, run, params, ops...)
+	}
+	if query == "-" && inputFile == "-" {
+		return nil, fmt.Errorf("%w: input and query both read from stdin", ErrQueryConflict)
+	}
+	return LoadQuery(query, queryFile, stdin, ops...)
+}
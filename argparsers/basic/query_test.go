@@ -0,0 +1,135 @@
+package basic
+
+import (
+	"errors"
+	iofs "io/fs"
+	"pimtrace/fsys/fsystest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "Lines and comments",
+			s:    "# Weekly senders\nfilter h.From icontains .example # only ours\n\tinto summary\n",
+			want: []string{"filter", "h.From", "icontains", ".example", "into", "summary"},
+		},
+		{
+			name: "Hash inside an argument is kept",
+			s:    "filter h.Subject contains .#1",
+			want: []string{"filter", "h.Subject", "contains", ".#1"},
+		},
+		{
+			name: "Quoted strings are kept whole with their quotes",
+			s:    "filter h.Subject eq \"hello # world\"\nf.as[h.a,'x\\'s y']",
+			want: []string{"filter", "h.Subject", "eq", `"hello # world"`, `f.as[h.a,'x\'s y']`},
+		},
+		{
+			name: "Apostrophes inside constants",
+			s:    "filter h.Subject eq .it's",
+			want: []string{"filter", "h.Subject", "eq", ".it's"},
+		},
+		{
+			name: "Empty",
+			s:    "\n# nothing\n",
+		},
+		{
+			name:    "Unterminated string",
+			s:       "filter\nh.Subject eq \"abc",
+			wantErr: ErrUnterminatedString,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitQuery(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SplitQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("SplitQuery() %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadQuery(t *testing.T) {
+	fs := fsystest.MapFSAdapter{MapFS: fstest.MapFS{
+		"report.query": {Data: []byte("# Report\nfilter h.From eq .a@x.com\n")},
+	}}
+	want := []string{"filter", "h.From", "eq", ".a@x.com"}
+	got, err := LoadQuery("", "report.query", nil, fs)
+	if err != nil {
+		t.Fatalf("LoadQuery() error = %v", err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("LoadQuery() file %s", diff)
+	}
+	got, err = LoadQuery("-", "", strings.NewReader("filter\nh.From eq .a@x.com"), fs)
+	if err != nil {
+		t.Fatalf("LoadQuery() error = %v", err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("LoadQuery() stdin %s", diff)
+	}
+	if _, err := LoadQuery("", "missing.query", nil, fs); !errors.Is(err, iofs.ErrNotExist) {
+		t.Errorf("LoadQuery() error = %v, want %v", err, iofs.ErrNotExist)
+	}
+	if _, err := LoadQuery("filter", "report.query", nil, fs); !errors.Is(err, ErrQueryConflict) {
+		t.Errorf("LoadQuery() error = %v, want %v", err, ErrQueryConflict)
+	}
+	got, err = LoadQuery("filter h.Subject contains #1", "", nil, fs)
+	if err != nil {
+		t.Fatalf("LoadQuery() error = %v", err)
+	}
+	if diff := cmp.Diff(got, []string{"filter", "h.Subject", "contains", "#1"}); diff != "" {
+		t.Errorf("LoadQuery() inline %s", diff)
+	}
+}
+
+func TestQueryArgs(t *testing.T) {
+	fs := fsystest.MapFSAdapter{MapFS: fstest.MapFS{
+		"report.query": {Data: []byte("filter h.From eq .a@x.com\n")},
+		"queries.conf": {Data: []byte("[from]\nfilter h.From eq .$who\n")},
+	}}
+	tests := []struct {
+		name      string
+		args      []string
+		query     string
+		queryFile string
+		inputFile string
+		run       string
+		params    Params
+		want      []string
+		wantErr   error
+	}{
+		{name: "Arguments", args: []string{"into", "table"}, want: []string{"into", "table"}},
+		{name: "Query", query: "into table", want: []string{"into", "table"}},
+		{name: "Query file", queryFile: "report.query", want: []string{"filter", "h.From", "eq", ".a@x.com"}},
+		{name: "Query from stdin", query: "-", inputFile: "mail.mbox", want: []string{"into", "table"}},
+		{name: "Saved query", run: "from", params: Params{"who": "b@y.com"}, want: []string{"filter", "h.From", "eq", ".b@y.com"}},
+		{name: "Query flag and arguments", args: []string{"into"}, query: "into table", wantErr: ErrQueryConflict},
+		{name: "Query and run", query: "into table", run: "from", wantErr: ErrQueryConflict},
+		{name: "Param without run", params: Params{"who": "b@y.com"}, wantErr: ErrQueryConflict},
+		{name: "Query and input from stdin", query: "-", inputFile: "-", wantErr: ErrQueryConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QueryArgs(tt.args, tt.query, tt.queryFile, tt.inputFile, tt.run, tt.params, "queries.conf", strings.NewReader("into table"), fs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("QueryArgs() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("QueryArgs() %s", diff)
+			}
+		})
+	}
+}
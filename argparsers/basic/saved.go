@@ -0,0 +1,284 @@
+package basic
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pimtrace/fsys"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidSavedQueries = fmt.Errorf("invalid saved queries")
+	ErrUnknownSavedQuery   = fmt.Errorf("unknown saved query")
+	ErrInvalidParameter    = fmt.Errorf("invalid parameter")
+	ErrMissingParameter    = fmt.Errorf("missing parameter")
+	ErrUnknownParameter    = fmt.Errorf("unknown parameter")
+)
+
+// SavedQuery is a named query script from a saved queries file, with `$name` or `${name}` placeholders for its
+// parameters.
+type SavedQuery struct {
+	Name string
+	// Description is from the comment lines directly after the name
+	Description string
+	Args        []string
+	// Params are the parameter names in order of first use
+	Params []string
+}
+
+// Params are `name=value` pairs for a SavedQuery. It is a flag.Value so it can be given as a repeated flag.
+type Params map[string]string
+
+func (p Params) String() string {
+	var ss []string
+	for k, v := range p {
+		ss = append(ss, k+"="+v)
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ",")
+}
+
+func (p Params) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("%w: %s, expected name=value", ErrInvalidParameter, s)
+	}
+	p[k] = v
+	return nil
+}
+
+// DefaultSavedQueriesFile is queries.conf in the pimtrace user config directory, eg: ~/.config/pimtrace/queries.conf
+func DefaultSavedQueriesFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pimtrace", "queries.conf")
+}
+
+// ParseSavedQueries parses a saved queries file, where each query is a `[name]` line followed by a query script, see
+// SplitQuery:
+//
+//	[monthly-senders]
+//	# Senders by month for a year
+//	filter f.year[h.Date] eq .$year
+//	into summary h.From f.month[h.Date] calculate f.count
+func ParseSavedQueries(s string) ([]*SavedQuery, error) {
+	var result []*SavedQuery
+	var q *SavedQuery
+	var body strings.Builder
+	start := 0
+	describing := false
+	names := map[string]struct{}{}
+	finish := func() error {
+		if q == nil {
+			return nil
+		}
+		args, err := splitQuery(body.String(), start, true)
+		if err != nil {
+			return fmt.Errorf("query %s: %w", q.Name, err)
+		}
+		q.Args = args
+		q.Params = params(args)
+		result = append(result, q)
+		return nil
+	}
+	for i, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: %w: empty query name", i+1, ErrInvalidSavedQueries)
+			}
+			if _, ok := names[name]; ok {
+				return nil, fmt.Errorf("line %d: %w: %s is defined twice", i+1, ErrInvalidSavedQueries, name)
+			}
+			names[name] = struct{}{}
+			q = &SavedQuery{Name: name}
+			body.Reset()
+			start = i + 2
+			describing = true
+			continue
+		}
+		if q == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				return nil, fmt.Errorf("line %d: %w: query before the first [name]", i+1, ErrInvalidSavedQueries)
+			}
+			continue
+		}
+		if describing {
+			if c, ok := strings.CutPrefix(trimmed, "#"); ok {
+				q.Description = strings.TrimSpace(q.Description + " " + strings.TrimSpace(c))
+			} else if trimmed != "" {
+				describing = false
+			}
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadSavedQueries reads and parses a saved queries file, see ParseSavedQueries.
+func ReadSavedQueries(r io.Reader) ([]*SavedQuery, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSavedQueries(string(b))
+}
+
+// LoadSavedQueries reads the saved queries file fName.
+//
+// Options: fsys.FS used to read fName
+func LoadSavedQueries(fName string, ops ...any) ([]*SavedQuery, error) {
+	fs := fsys.NewOSFS()
+	for _, op := range ops {
+		if o, ok := op.(fsys.FS); ok {
+			fs = o
+		}
+	}
+	f, err := fs.OpenFile(fName, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("reading saved queries %s: %w", fName, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	qs, err := ReadSavedQueries(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading saved queries %s: %w", fName, err)
+	}
+	return qs, nil
+}
+
+// RunSavedQuery returns the arguments of the saved query name in fName with params filled in.
+//
+// Options: fsys.FS used to read fName
+func RunSavedQuery(fName string, name string, params Params, ops ...any) ([]string, error) {
+	qs, err := LoadSavedQueries(fName, ops...)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range qs {
+		if q.Name == name {
+			return q.Expand(params)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s in %s", ErrUnknownSavedQuery, name, fName)
+}
+
+// Expand returns the query arguments with the parameters replaced by their values. Every parameter must be given
+// and every value must be used.
+func (q *SavedQuery) Expand(params Params) ([]string, error) {
+	for k := range params {
+		if !containsString(q.Params, k) {
+			return nil, fmt.Errorf("%w: %s for query %s", ErrUnknownParameter, k, q.Name)
+		}
+	}
+	var result []string
+	for _, arg := range q.Args {
+		s, err := expandParams(arg, func(name string) (string, error) {
+			v, ok := params[name]
+			if !ok {
+				return "", fmt.Errorf("%w: %s for query %s, use -param %s=value", ErrMissingParameter, name, q.Name, name)
+			}
+			return v, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// PrintSavedQueries lists the saved queries with their parameters and descriptions.
+func PrintSavedQueries(w io.Writer, qs []*SavedQuery) {
+	for _, q := range qs {
+		_, _ = fmt.Fprint(w, q.Name)
+		for _, p := range q.Params {
+			_, _ = fmt.Fprintf(w, " $%s", p)
+		}
+		_, _ = fmt.Fprintln(w)
+		if q.Description != "" {
+			_, _ = fmt.Fprintf(w, "\t%s\n", q.Description)
+		}
+	}
+}
+
+func params(args []string) []string {
+	var result []string
+	for _, arg := range args {
+		_, _ = expandParams(arg, func(name string) (string, error) {
+			if !containsString(result, name) {
+				result = append(result, name)
+			}
+			return "", nil
+		})
+	}
+	return result
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// expandParams replaces `$name` and `${name}` in s with the value from lookup. `$$` is a single `$` and any other
+// `$`, such as the end of line in `matches .^Re:.*$`, is left as it is.
+func expandParams(s string, lookup func(name string) (string, error)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		var name string
+		end := i + 1
+		switch {
+		case s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case s[i+1] == '{':
+			if n := strings.IndexByte(s[i+2:], '}'); n > 0 {
+				name = s[i+2 : i+2+n]
+				end = i + 3 + n
+			}
+		default:
+			for end < len(s) && isParamChar(s[end]) {
+				end++
+			}
+			name = s[i+1 : end]
+		}
+		if name == "" {
+			b.WriteByte(s[i])
+			continue
+		}
+		v, err := lookup(name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+		i = end - 1
+	}
+	return b.String(), nil
+}
+
+func isParamChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
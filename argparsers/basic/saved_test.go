@@ -0,0 +1,161 @@
+package basic
+
+import (
+	"bytes"
+	"errors"
+	iofs "io/fs"
+	"pimtrace/fsys/fsystest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const savedQueries = `# Team reports
+
+[monthly-senders]
+# Senders by month
+# for a year
+filter f.year[h.Date] eq .$year
+into summary h.From f.month[h.Date] calculate f.count
+
+[from]
+filter h.From eq ${sender} and h.Subject matches .^Re:.*$ and h.X eq .$$year
+`
+
+func TestParseSavedQueries(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []*SavedQuery
+		wantErr error
+	}{
+		{
+			name: "Queries with descriptions and parameters",
+			s:    savedQueries,
+			want: []*SavedQuery{
+				{
+					Name:        "monthly-senders",
+					Description: "Senders by month for a year",
+					Args:        []string{"filter", "f.year[h.Date]", "eq", ".$year", "into", "summary", "h.From", "f.month[h.Date]", "calculate", "f.count"},
+					Params:      []string{"year"},
+				},
+				{
+					Name:   "from",
+					Args:   []string{"filter", "h.From", "eq", "${sender}", "and", "h.Subject", "matches", ".^Re:.*$", "and", "h.X", "eq", ".$$year"},
+					Params: []string{"sender"},
+				},
+			},
+		},
+		{
+			name:    "Query before a name",
+			s:       "filter h.From eq .a\n[a]\n",
+			wantErr: ErrInvalidSavedQueries,
+		},
+		{
+			name:    "Duplicate name",
+			s:       "[a]\nfilter h.From eq .a\n[a]\n",
+			wantErr: ErrInvalidSavedQueries,
+		},
+		{
+			name:    "Unterminated string",
+			s:       "[a]\nfilter h.From eq \"a\n",
+			wantErr: ErrUnterminatedString,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSavedQueries(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSavedQueries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("ParseSavedQueries() %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunSavedQuery(t *testing.T) {
+	fs := fsystest.MapFSAdapter{MapFS: fstest.MapFS{
+		"queries.conf": {Data: []byte(savedQueries)},
+	}}
+	tests := []struct {
+		name    string
+		query   string
+		params  Params
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "Parameter inside a constant",
+			query:  "monthly-senders",
+			params: Params{"year": "2024"},
+			want:   []string{"filter", "f.year[h.Date]", "eq", ".2024", "into", "summary", "h.From", "f.month[h.Date]", "calculate", "f.count"},
+		},
+		{
+			name:   "Braced parameter, regexp end and escaped dollar",
+			query:  "from",
+			params: Params{"sender": ".a@x.com"},
+			want:   []string{"filter", "h.From", "eq", ".a@x.com", "and", "h.Subject", "matches", ".^Re:.*$", "and", "h.X", "eq", ".$year"},
+		},
+		{
+			name:    "Missing parameter",
+			query:   "monthly-senders",
+			wantErr: ErrMissingParameter,
+		},
+		{
+			name:    "Unknown parameter",
+			query:   "monthly-senders",
+			params:  Params{"year": "2024", "yaer": "2024"},
+			wantErr: ErrUnknownParameter,
+		},
+		{
+			name:    "Unknown query",
+			query:   "nope",
+			wantErr: ErrUnknownSavedQuery,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunSavedQuery("queries.conf", tt.query, tt.params, fs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunSavedQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("RunSavedQuery() %s", diff)
+			}
+		})
+	}
+	if _, err := RunSavedQuery("missing.conf", "from", nil, fs); !errors.Is(err, iofs.ErrNotExist) {
+		t.Errorf("RunSavedQuery() error = %v, want %v", err, iofs.ErrNotExist)
+	}
+}
+
+func TestParams_Set(t *testing.T) {
+	p := Params{}
+	for _, s := range []string{"year=2024", "sender=.a=b"} {
+		if err := p.Set(s); err != nil {
+			t.Fatalf("Set(%q) error = %v", s, err)
+		}
+	}
+	if got := p.String(); got != "sender=.a=b,year=2024" {
+		t.Errorf("String() = %q", got)
+	}
+	if err := p.Set("year"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Set() error = %v, want %v", err, ErrInvalidParameter)
+	}
+}
+
+func TestPrintSavedQueries(t *testing.T) {
+	qs, err := ParseSavedQueries(savedQueries)
+	if err != nil {
+		t.Fatalf("ParseSavedQueries() error = %v", err)
+	}
+	b := &bytes.Buffer{}
+	PrintSavedQueries(b, qs)
+	want := "monthly-senders $year\n\tSenders by month for a year\nfrom $sender\n"
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("PrintSavedQueries() %s", diff)
+	}
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"pimtrace"
@@ -17,6 +18,7 @@ import (
 
 var (
 	ErrUnknownFunction = fmt.Errorf("unknown function")
+	ErrUnknownOperator = fmt.Errorf("unknown operator")
 )
 
 type Operation interface {
@@ -136,12 +138,75 @@ func (ve EntryExpression) Evaluate(d interface{}, opts ...any) (interface{}, err
 
 type OpFunc func(pimtrace.Value, pimtrace.Value) (bool, error)
 
+// Ops are the OpFunc an Op can refer to by name
+var Ops = map[string]OpFunc{
+	"eq":         EqualOp,
+	"ne":         NotEqualOp,
+	"lt":         LessOp,
+	"le":         LessEqualOp,
+	"gt":         GreaterOp,
+	"ge":         GreaterEqualOp,
+	"contains":   ContainsOp,
+	"icontains":  IContainsOp,
+	"startswith": StartsWithOp,
+	"endswith":   EndsWithOp,
+	"matches":    MatchesOp,
+	"imatches":   IMatchesOp,
+	"like":       LikeOp,
+}
+
 func EqualOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
-	return rhsv.String() == lhsv.String(), nil
+	return pimtrace.Compare(rhsv, lhsv) == 0, nil
 }
 
 var _ OpFunc = EqualOp
 
+func NotEqualOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	return pimtrace.Compare(rhsv, lhsv) != 0, nil
+}
+
+var _ OpFunc = NotEqualOp
+
+// ordered compares two values for the range operators, a missing or empty value is never in range.
+func ordered(rhsv pimtrace.Value, lhsv pimtrace.Value) (int, bool) {
+	if isBlank(rhsv) || isBlank(lhsv) {
+		return 0, false
+	}
+	return pimtrace.Compare(rhsv, lhsv), true
+}
+
+func isBlank(v pimtrace.Value) bool {
+	return v == nil || v.Type() == pimtrace.Nil || v.Type() == pimtrace.String && v.String() == ""
+}
+
+func LessOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	c, ok := ordered(rhsv, lhsv)
+	return ok && c < 0, nil
+}
+
+var _ OpFunc = LessOp
+
+func LessEqualOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	c, ok := ordered(rhsv, lhsv)
+	return ok && c <= 0, nil
+}
+
+var _ OpFunc = LessEqualOp
+
+func GreaterOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	c, ok := ordered(rhsv, lhsv)
+	return ok && c > 0, nil
+}
+
+var _ OpFunc = GreaterOp
+
+func GreaterEqualOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	c, ok := ordered(rhsv, lhsv)
+	return ok && c >= 0, nil
+}
+
+var _ OpFunc = GreaterEqualOp
+
 func ContainsOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
 	return strings.Contains(rhsv.String(), lhsv.String()), nil
 }
@@ -164,25 +229,66 @@ func (e *Op) Evaluate(d interface{}, opts ...any) (bool, error) {
 	if e.LHS == nil || e.RHS == nil {
 		return false, fmt.Errorf("missing operands")
 	}
+	op, ok := Ops[e.Op]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrUnknownOperator, e.Op)
+	}
+	vs, err := operandValues(d, opts, e.LHS, e.RHS)
+	if err != nil {
+		return false, err
+	}
+	return op(vs[0], vs[1])
+}
+
+// Between is true when Value is within Low and High inclusive.
+type Between struct {
+	Value ValueExpression
+	Low   ValueExpression
+	High  ValueExpression
+}
+
+func (e *Between) Evaluate(d interface{}, opts ...any) (bool, error) {
+	if e.Value == nil || e.Low == nil || e.High == nil {
+		return false, fmt.Errorf("missing operands")
+	}
+	vs, err := operandValues(d, opts, e.Value, e.Low, e.High)
+	if err != nil {
+		return false, err
+	}
+	if ok, err := GreaterEqualOp(vs[0], vs[1]); err != nil || !ok {
+		return false, err
+	}
+	return LessEqualOp(vs[0], vs[2])
+}
 
+// operandValues executes each expression against the entry being evaluated, missing results become nil values.
+func operandValues(d interface{}, opts []any, es ...ValueExpression) ([]pimtrace.Value, error) {
 	if w, ok := d.(evaluatorEntryWrapper); ok {
 		d = w.Entry
 	}
 	eEntry, ok := d.(pimtrace.Entry)
 	if !ok {
-		return false, fmt.Errorf("invalid data type for Op evaluate")
+		return nil, fmt.Errorf("invalid data type for Op evaluate")
 	}
-
-	expr := evaluator.ComparisonExpression{
-		LHS:       evaluator.Self{},
-		RHS:       evaluator.Self{},
-		Operation: e.Op,
+	var ctx *evaluator.Context
+	for _, opt := range opts {
+		if c, ok := opt.(*evaluator.Context); ok {
+			ctx = c
+		}
 	}
-
-	expr.LHS = e.LHS
-	expr.RHS = e.RHS
-
-	return expr.Evaluate(eEntry, opts...)
+	result := make([]pimtrace.Value, len(es))
+	for i, e := range es {
+		v, err := e.Execute(eEntry, ctx)
+		if err != nil && !errors.Is(err, tabledata.ErrKeyNotFound) {
+			return nil, err
+		}
+		if v == nil {
+			// Rows don't all have the same columns once extend or rename has changed them
+			v = &pimtrace.SimpleNilValue{}
+		}
+		result[i] = v
+	}
+	return result, nil
 }
 
 type FilterStatement struct {
@@ -262,12 +368,21 @@ type EvaluatorFunctionExpression struct {
 }
 
 func (fe *EvaluatorFunctionExpression) ColumnName() string {
-	elems := []string{fe.Function}
+	var args []ValueExpression
 	for _, arg := range fe.Args {
 		if c, ok := arg.(ValueExpression); ok {
-			elems = append(elems, c.ColumnName())
+			args = append(args, c)
+		}
+	}
+	if f, ok := funcs.Functions[ValueExpression]()[fe.Function].(funcs.ColumnNamer[ValueExpression]); ok {
+		if v := f.ColumnName(args); len(v) > 0 {
+			return v
 		}
 	}
+	elems := []string{fe.Function}
+	for _, arg := range args {
+		elems = append(elems, arg.ColumnName())
+	}
 	return strings.Join(elems, "-")
 }
 
@@ -295,9 +410,11 @@ func toPimtraceValue(v interface{}) (pimtrace.Value, error) {
 	case int64:
 		return pimtrace.SimpleIntegerValue(int(val)), nil
 	case float64:
-		return pimtrace.SimpleIntegerValue(int(val)), nil // Lossy? pimtrace seems to use int mostly
+		return pimtrace.SimpleFloatValue(val), nil
 	case string:
 		return pimtrace.SimpleStringValue(val), nil
+	case bool:
+		return pimtrace.SimpleBoolValue(val), nil
 	case []interface{}:
 		var arr []pimtrace.Value
 		for _, item := range val {
@@ -332,34 +449,84 @@ type TableTransformer struct {
 }
 
 func (t *TableTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	headers := t.headers()
+	td := make([]*tabledata.Row, d.Len())
+	for i := 0; i < d.Len(); i++ {
+		var err error
+		td[i], err = t.row(d.Entry(i), headers, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tabledata.Data(td), nil
+}
+
+func (t *TableTransformer) headers() map[string]int {
 	headers := map[string]int{}
 	for i, c := range t.Columns {
 		headers[c.Name] = i
 	}
-	td := make([]*tabledata.Row, d.Len())
-	for i := 0; i < d.Len(); i++ {
-		r := make([]pimtrace.Value, len(t.Columns))
-		e := d.Entry(i)
-		for i, c := range t.Columns {
-			// Context is now passed to all ValueExpressions
-			v, err := c.Operation.Execute(e, ctx)
-			if err != nil {
-				return nil, err
-			}
-			r[i] = v
-		}
-		td[i] = &tabledata.Row{
-			Headers: headers,
-			Row:     r,
+	return headers
+}
+
+func (t *TableTransformer) row(e pimtrace.Entry, headers map[string]int, ctx *evaluator.Context) (*tabledata.Row, error) {
+	r := make([]pimtrace.Value, len(t.Columns))
+	for i, c := range t.Columns {
+		// Context is now passed to all ValueExpressions
+		v, err := c.Operation.Execute(e, ctx)
+		if err != nil {
+			return nil, err
 		}
+		r[i] = v
 	}
-	return tabledata.Data(td), nil
+	return &tabledata.Row{
+		Headers: headers,
+		Row:     r,
+	}, nil
 }
 
 var _ Operation = (*TableTransformer)(nil)
 
 type SortTransformer struct {
 	Expression []ValueExpression
+	// Order is the order of each Expression, Expressions without one are ascending
+	Order []SortOrder
+}
+
+// Nulls is where missing and empty values sort, they are equal to each other in every mode.
+type Nulls int
+
+const (
+	// NullsDefault collates them before everything else, so first ascending and last descending
+	NullsDefault Nulls = iota
+	NullsFirst
+	NullsLast
+)
+
+type SortOrder struct {
+	Descending bool
+	Nulls      Nulls
+}
+
+func (o SortOrder) String() string {
+	s := "asc"
+	if o.Descending {
+		s = "desc"
+	}
+	switch o.Nulls {
+	case NullsFirst:
+		s += " nulls first"
+	case NullsLast:
+		s += " nulls last"
+	}
+	return s
+}
+
+func (s *SortTransformer) order(i int) SortOrder {
+	if i < len(s.Order) {
+		return s.Order[i]
+	}
+	return SortOrder{}
 }
 
 type SortTransformerSorter struct {
@@ -373,28 +540,51 @@ func (s *SortTransformerSorter) Len() int {
 }
 
 func (s *SortTransformerSorter) Less(i, j int) bool {
-	for _, e := range s.SortTransformer.Expression {
-		io, jo := s.Data.Entry(i), s.Data.Entry(j)
-		iv, err := e.Execute(io, s.Context)
+	return s.SortTransformer.compare(s.SortTransformer.keys(s.Data.Entry(i), s.Context), s.SortTransformer.keys(s.Data.Entry(j), s.Context)) < 0
+}
+
+// keys evaluates the sort expressions for e, errors are logged and sort as missing values.
+func (s *SortTransformer) keys(e pimtrace.Entry, ctx *evaluator.Context) []pimtrace.Value {
+	result := make([]pimtrace.Value, len(s.Expression))
+	for k, ex := range s.Expression {
+		v, err := ex.Execute(e, ctx)
 		if err != nil {
-			log.Printf("Sort execution error for element i: %v", err)
+			log.Printf("Sort execution error: %v", err)
 		}
-		if iv == nil {
-			iv = &pimtrace.SimpleNilValue{}
+		if v == nil {
+			v = &pimtrace.SimpleNilValue{}
 		}
-		jv, err := e.Execute(jo, s.Context)
-		if err != nil {
-			log.Printf("Sort execution error for element j: %v", err)
-		}
-		if jv == nil {
-			jv = &pimtrace.SimpleNilValue{}
+		result[k] = v
+	}
+	return result
+}
+
+// compare compares keys from keys by the order of each expression.
+func (s *SortTransformer) compare(iks, jks []pimtrace.Value) int {
+	for k := range iks {
+		iv, jv := iks[k], jks[k]
+		o := s.order(k)
+		// Empty strings are null here as for the range operators, whichever way nulls are placed
+		ib, jb := isBlank(iv), isBlank(jv)
+		if ib != jb {
+			first := o.Nulls == NullsFirst || o.Nulls == NullsDefault && !o.Descending
+			if ib == first {
+				return -1
+			}
+			return 1
 		}
-		if iv.Equal(jv) {
+		if ib {
 			continue
 		}
-		return iv.Less(jv)
+		c := pimtrace.Compare(iv, jv)
+		if o.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
 	}
-	return i < j
+	return 0
 }
 
 func (s *SortTransformerSorter) Swap(i, j int) {
@@ -404,7 +594,8 @@ func (s *SortTransformerSorter) Swap(i, j int) {
 }
 
 func (s *SortTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
-	sort.Sort(&SortTransformerSorter{
+	// Stable so entries with equal keys keep their input order
+	sort.Stable(&SortTransformerSorter{
 		SortTransformer: s,
 		Data:            d,
 		Context:         ctx,
@@ -435,7 +626,7 @@ func (g *GroupTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pim
 			}
 			r[i] = v
 		}
-		key := pimtrace.SimpleArrayValue(r).String()
+		key := pimtrace.Key(pimtrace.SimpleArrayValue(r))
 		if p, ok := pos[key]; ok {
 			td[p].Contents = td[p].Contents.SetEntry(td[p].Contents.Len(), e)
 		} else {
@@ -3,12 +3,19 @@ package ast
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"pimtrace"
+	"pimtrace/dataformats/groupdata"
+	"pimtrace/dataformats/maildata"
 	"pimtrace/dataformats/tabledata"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/arran4/go-evaluator"
+	"github.com/emersion/go-message/mail"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"fmt"
 )
 
@@ -41,6 +48,9 @@ func Valueify(ss ...any) (result []pimtrace.Value) {
 		case int:
 			result = append(result, pimtrace.SimpleIntegerValue(s))
 			continue
+		case nil:
+			result = append(result, &pimtrace.SimpleNilValue{})
+			continue
 		default:
 			panic("unsupported type")
 		}
@@ -49,7 +59,7 @@ func Valueify(ss ...any) (result []pimtrace.Value) {
 }
 
 func TestCompoundStatement_Execute(t *testing.T) {
-	header1 := map[string]int{"address": 3, "currency": 4, "email": 2, "name": 0, "numberrange": 5, "phone": 1}
+	header1 := map[string]int{"address": 3, "currency": 4, "email": 2, "name": 0, "numberrange": 5, "overflow": 6, "phone": 1}
 	header2 := map[string]int{"Name": 0}
 	header3 := map[string]int{"Number": 0, "count": 1, "sum-size": 2}
 	header4 := map[string]int{"Count": 2, "Month": 1, "Year": 0}
@@ -73,7 +83,7 @@ func TestCompoundStatement_Execute(t *testing.T) {
 					Headers: header1,
 					Row: Valueify(
 						"Jasper Joseph", "(125) 832-4826", "mauris.vestibulum@protonmail.edu",
-						"Ap #783-8034 Nunc Street", "$73.44", "4",
+						"Ap #783-8034 Nunc Street", "$73.44", "4", nil,
 					),
 				},
 			},
@@ -109,7 +119,7 @@ func TestCompoundStatement_Execute(t *testing.T) {
 						{Name: "Name", Operation: EntryExpression("h.name")},
 					},
 				},
-				&SortTransformer{[]ValueExpression{EntryExpression("c.Name")}},
+				&SortTransformer{Expression: []ValueExpression{EntryExpression("c.Name")}},
 			}},
 			data: LoadData1("testdata/data10.csv"),
 			want: tabledata.Data{
@@ -333,6 +343,616 @@ func TestSortTransformer_Execute(t *testing.T) {
 	}
 }
 
+func TestSortTransformer_ExecuteNumericStrings(t *testing.T) {
+	header := map[string]int{"Amount": 0}
+	d := tabledata.Data{
+		{Headers: header, Row: Valueify("100")},
+		{Headers: header, Row: Valueify("20")},
+		{Headers: header, Row: []pimtrace.Value{&pimtrace.SimpleNilValue{}}},
+		{Headers: header, Row: Valueify("3.5")},
+		{Headers: header, Row: Valueify("n/a")},
+	}
+	got, err := (&SortTransformer{Expression: []ValueExpression{EntryExpression("c.Amount")}}).Execute(d, nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := tabledata.Data{
+		{Headers: header, Row: []pimtrace.Value{&pimtrace.SimpleNilValue{}}},
+		{Headers: header, Row: Valueify("3.5")},
+		{Headers: header, Row: Valueify("20")},
+		{Headers: header, Row: Valueify("100")},
+		{Headers: header, Row: Valueify("n/a")},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Execute() \n%s", diff)
+	}
+}
+
+// sortOrderData is rows of From, count and id where id is the input order.
+func sortOrderData() tabledata.Data {
+	header := map[string]int{"From": 0, "count": 1, "id": 2}
+	row := func(from string, count pimtrace.Value, id string) *tabledata.Row {
+		return &tabledata.Row{Headers: header, Row: []pimtrace.Value{pimtrace.SimpleStringValue(from), count, pimtrace.SimpleStringValue(id)}}
+	}
+	return tabledata.Data{
+		row("c", pimtrace.SimpleIntegerValue(2), "1"),
+		row("a", &pimtrace.SimpleNilValue{}, "2"),
+		row("b", pimtrace.SimpleIntegerValue(5), "3"),
+		row("a", pimtrace.SimpleIntegerValue(2), "4"),
+		row("a", pimtrace.SimpleIntegerValue(2), "5"),
+	}
+}
+
+func sortOrderIds(d pimtrace.Data) string {
+	var ids []string
+	for _, r := range d.(tabledata.Data) {
+		ids = append(ids, r.Row[2].String())
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestSortTransformer_ExecuteOrder(t *testing.T) {
+	count, from := EntryExpression("c.count"), EntryExpression("c.From")
+	for _, test := range []struct {
+		Name  string
+		Sort  *SortTransformer
+		Order string
+	}{
+		{Name: "Descending then ascending", Sort: &SortTransformer{Expression: []ValueExpression{count, from}, Order: []SortOrder{{Descending: true}}}, Order: "3 4 5 1 2"},
+		{Name: "Descending nulls first", Sort: &SortTransformer{Expression: []ValueExpression{count}, Order: []SortOrder{{Descending: true, Nulls: NullsFirst}}}, Order: "2 3 1 4 5"},
+		{Name: "Ascending nulls last", Sort: &SortTransformer{Expression: []ValueExpression{count}, Order: []SortOrder{{Nulls: NullsLast}}}, Order: "1 4 5 3 2"},
+		{Name: "Equal keys keep their order", Sort: &SortTransformer{Expression: []ValueExpression{from}}, Order: "2 4 5 3 1"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Sort.Execute(sortOrderData(), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if diff := cmp.Diff(sortOrderIds(got), test.Order); diff != "" {
+				t.Errorf("Execute() \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSortTransformer_ExecuteBlanks(t *testing.T) {
+	key := EntryExpression("c.key")
+	data := func() tabledata.Data {
+		header := map[string]int{"key": 0, "id": 1}
+		var d tabledata.Data
+		for i, v := range []pimtrace.Value{pimtrace.SimpleStringValue("b"), pimtrace.SimpleStringValue(""), &pimtrace.SimpleNilValue{}, pimtrace.SimpleStringValue("a")} {
+			d = append(d, &tabledata.Row{Headers: header, Row: []pimtrace.Value{v, pimtrace.SimpleStringValue(fmt.Sprint(i + 1))}})
+		}
+		return d
+	}
+	for _, test := range []struct {
+		Name  string
+		Order SortOrder
+		Want  string
+	}{
+		{Name: "Ascending", Order: SortOrder{}, Want: "2 3 4 1"},
+		{Name: "Descending", Order: SortOrder{Descending: true}, Want: "1 4 2 3"},
+		{Name: "Ascending nulls last", Order: SortOrder{Nulls: NullsLast}, Want: "4 1 2 3"},
+		{Name: "Descending nulls first", Order: SortOrder{Descending: true, Nulls: NullsFirst}, Want: "2 3 1 4"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			s := &SortTransformer{Expression: []ValueExpression{key}, Order: []SortOrder{test.Order}}
+			got, err := s.Execute(data(), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			var ids []string
+			for _, r := range got.(tabledata.Data) {
+				ids = append(ids, r.Row[1].String())
+			}
+			if diff := cmp.Diff(strings.Join(ids, " "), test.Want); diff != "" {
+				t.Errorf("Execute() \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLimitTransformers_Execute(t *testing.T) {
+	count, from := EntryExpression("c.count"), EntryExpression("c.From")
+	for _, test := range []struct {
+		Name  string
+		Op    Operation
+		Order string
+	}{
+		{Name: "Limit", Op: &LimitTransformer{N: 2}, Order: "1 2"},
+		{Name: "Limit past the end", Op: &LimitTransformer{N: 10}, Order: "1 2 3 4 5"},
+		{Name: "Limit zero", Op: &LimitTransformer{N: 0}, Order: ""},
+		{Name: "Offset", Op: &OffsetTransformer{N: 3}, Order: "4 5"},
+		{Name: "Offset past the end", Op: &OffsetTransformer{N: 10}, Order: ""},
+		{Name: "Top", Op: &TopTransformer{N: 2, Sort: &SortTransformer{Expression: []ValueExpression{count}, Order: []SortOrder{{Descending: true}}}}, Order: "3 1"},
+		{Name: "Top keeps the first of equal keys", Op: &TopTransformer{N: 3, Sort: &SortTransformer{Expression: []ValueExpression{from}}}, Order: "2 4 5"},
+		{Name: "Top mixed order", Op: &TopTransformer{N: 4, Sort: &SortTransformer{Expression: []ValueExpression{count, from}, Order: []SortOrder{{Descending: true}}}}, Order: "3 4 5 1"},
+		{Name: "Top nulls first", Op: &TopTransformer{N: 2, Sort: &SortTransformer{Expression: []ValueExpression{count}, Order: []SortOrder{{Nulls: NullsFirst}}}}, Order: "2 1"},
+		{Name: "Top more than there are", Op: &TopTransformer{N: 10, Sort: &SortTransformer{Expression: []ValueExpression{from}}}, Order: "2 4 5 3 1"},
+		{Name: "Top zero", Op: &TopTransformer{N: 0, Sort: &SortTransformer{Expression: []ValueExpression{from}}}, Order: ""},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Op.Execute(sortOrderData(), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if diff := cmp.Diff(sortOrderIds(got), test.Order); diff != "" {
+				t.Errorf("Execute() \n%s", diff)
+			}
+			s, err := ExecuteStream(test.Op, pimtrace.DataStream(sortOrderData()), nil)
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			got, err = pimtrace.Collect(s)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if diff := cmp.Diff(sortOrderIds(got), test.Order); diff != "" {
+				t.Errorf("ExecuteStream() \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDistinctTransformer_Execute(t *testing.T) {
+	message := func(id, subject string) *maildata.MailWithSource {
+		h := mail.Header{}
+		h.Set("Message-Id", id)
+		h.Set("Subject", subject)
+		return &maildata.MailWithSource{MailHeader: h}
+	}
+	mails := func() pimtrace.Data {
+		return maildata.Data{message("a", "1"), message("b", "2"), message("a", "3"), message("a", "1")}
+	}
+	subjects := func(d pimtrace.Data) string {
+		var ss []string
+		for _, m := range d.(maildata.Data) {
+			ss = append(ss, m.MailHeader.Get("Subject"))
+		}
+		return strings.Join(ss, " ")
+	}
+	for _, test := range []struct {
+		Name    string
+		Op      *DistinctTransformer
+		Data    func() pimtrace.Data
+		Ids     func(pimtrace.Data) string
+		Want    string
+		Dropped int
+	}{
+		{Name: "Table by a column", Op: &DistinctTransformer{Expression: []ValueExpression{EntryExpression("c.From")}}, Data: func() pimtrace.Data { return sortOrderData() }, Ids: sortOrderIds, Want: "1 2 3", Dropped: 2},
+		{Name: "Table by two columns", Op: &DistinctTransformer{Expression: []ValueExpression{EntryExpression("c.From"), EntryExpression("c.count")}}, Data: func() pimtrace.Data { return sortOrderData() }, Ids: sortOrderIds, Want: "1 2 3 4", Dropped: 1},
+		{Name: "Table whole rows", Op: &DistinctTransformer{}, Data: func() pimtrace.Data { return sortOrderData() }, Ids: sortOrderIds, Want: "1 2 3 4 5"},
+		{Name: "Mail by message id", Op: &DistinctTransformer{Expression: []ValueExpression{EntryExpression("h.Message-Id")}}, Data: mails, Ids: subjects, Want: "1 2", Dropped: 2},
+		{Name: "Mail whole headers", Op: &DistinctTransformer{}, Data: mails, Ids: subjects, Want: "1 2 3", Dropped: 1},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Op.Execute(test.Data(), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if diff := cmp.Diff(test.Ids(got), test.Want); diff != "" {
+				t.Errorf("Execute() \n%s", diff)
+			}
+			if test.Op.Dropped != test.Dropped {
+				t.Errorf("Execute() Dropped = %d, want %d", test.Op.Dropped, test.Dropped)
+			}
+			s, err := ExecuteStream(test.Op, pimtrace.DataStream(test.Data()), nil)
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			if got, err = pimtrace.Collect(s); err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if diff := cmp.Diff(test.Ids(got), test.Want); diff != "" {
+				t.Errorf("ExecuteStream() \n%s", diff)
+			}
+			if test.Op.Dropped != test.Dropped {
+				t.Errorf("ExecuteStream() Dropped = %d, want %d", test.Op.Dropped, test.Dropped)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	distinct := &DistinctTransformer{Expression: []ValueExpression{EntryExpression("c.From")}}
+	op := &CompoundStatement{Statements: []Operation{distinct, &LimitTransformer{N: 2}}}
+	var reports []string
+	logf := func(format string, args ...any) {
+		reports = append(reports, fmt.Sprintf(format, args...))
+	}
+	s, err := ExecuteStream(op, pimtrace.DataStream(sortOrderData()), nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if _, err := pimtrace.Collect(s); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	Report(op, logf)
+	if diff := cmp.Diff(reports, []string{"distinct: dropped 0 duplicates"}); diff != "" {
+		t.Errorf("Report() after a limit \n%s", diff)
+	}
+	reports = nil
+	if _, err := op.Execute(sortOrderData(), nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	Report(op, logf)
+	if diff := cmp.Diff(reports, []string{"distinct: dropped 2 duplicates"}); diff != "" {
+		t.Errorf("Report() \n%s", diff)
+	}
+}
+
+func TestHaving(t *testing.T) {
+	count := &FunctionExpression{Function: "count"}
+	n := &EvaluatorFunctionExpression{Function: "as", FunctionExpression: evaluator.FunctionExpression{
+		Name: "as",
+		Args: []evaluator.Term{count, ConstantExpression("n")},
+	}}
+	calculated := []*ColumnExpression{{Name: "count", Operation: count}, {Name: "n", Operation: n}}
+	for _, test := range []struct {
+		Name      string
+		Condition evaluator.Expression
+		Want      evaluator.Expression
+		From      string
+	}{
+		{
+			Name:      "Function",
+			Condition: &Op{Op: "gt", LHS: count, RHS: ConstantExpression("1")},
+			Want:      &Op{Op: "gt", LHS: count, RHS: ConstantExpression("1")},
+			From:      "a",
+		},
+		{
+			Name:      "Column name",
+			Condition: &evaluator.IsExpression{Field: "count", Value: "1"},
+			Want:      &Op{Op: "eq", LHS: count, RHS: ConstantExpression("1")},
+			From:      "c b",
+		},
+		{
+			Name: "As name and a group column",
+			Condition: &evaluator.AndExpression{Expressions: []evaluator.Query{
+				{Expression: &Between{Value: EntryExpression("c.n"), Low: ConstantExpression("1"), High: ConstantExpression("2")}},
+				{Expression: &evaluator.NotExpression{Expression: evaluator.Query{Expression: NewIn(EntryExpression("c.From"), []string{"b"})}}},
+			}},
+			Want: &evaluator.AndExpression{Expressions: []evaluator.Query{
+				{Expression: &Between{Value: count, Low: ConstantExpression("1"), High: ConstantExpression("2")}},
+				{Expression: &evaluator.NotExpression{Expression: evaluator.Query{Expression: NewIn(EntryExpression("c.From"), []string{"b"})}}},
+			}},
+			From: "c",
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got := Having(&evaluator.Query{Expression: test.Condition}, calculated)
+			if diff := cmp.Diff(got.Expression, test.Want, cmpopts.IgnoreFields(FunctionExpression{}, "F")); diff != "" {
+				t.Errorf("Having() \n%s", diff)
+			}
+			op := &CompoundStatement{Statements: []Operation{
+				&GroupTransformer{Columns: []*ColumnExpression{{Name: "From", Operation: EntryExpression("c.From")}}},
+				&FilterStatement{Expression: got},
+			}}
+			d, err := op.Execute(sortOrderData(), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			var from []string
+			for i := 0; i < d.Len(); i++ {
+				v, err := d.Entry(i).Get("c.From")
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				from = append(from, v.String())
+			}
+			if diff := cmp.Diff(strings.Join(from, " "), test.From); diff != "" {
+				t.Errorf("Execute() \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExtendTransformers_Execute(t *testing.T) {
+	h := mail.Header{}
+	h.Set("Subject", "hello")
+	h.Set("X-Mailer", "kmail")
+	mails := func() pimtrace.Data {
+		return maildata.Data{&maildata.MailWithSource{MailHeader: h}}
+	}
+	for _, test := range []struct {
+		Name string
+		Op   Operation
+		Data func() pimtrace.Data
+		Want [][]string
+	}{
+		{
+			Name: "Extend adds and replaces columns",
+			Op: &ExtendTransformer{Columns: []*ColumnExpression{
+				{Name: "Copy", Operation: EntryExpression("c.From")},
+				{Name: "id", Operation: ConstantExpression("x")},
+			}},
+			Data: func() pimtrace.Data { return sortOrderData()[:2] },
+			Want: [][]string{{"From", "count", "id", "Copy"}, {"c", "2", "x", "c"}, {"a", "", "x", "a"}},
+		},
+		{
+			Name: "Drop",
+			Op:   &DropTransformer{Columns: []string{"count"}},
+			Data: func() pimtrace.Data { return sortOrderData()[:1] },
+			Want: [][]string{{"From", "id"}, {"c", "1"}},
+		},
+		{
+			Name: "Rename",
+			Op:   &RenameTransformer{Renames: []Rename{{From: "From", To: "Sender"}, {From: "missing", To: "x"}}},
+			Data: func() pimtrace.Data { return sortOrderData()[:1] },
+			Want: [][]string{{"Sender", "count", "id"}, {"c", "2", "1"}},
+		},
+		{
+			Name: "Mail keeps its headers",
+			Op: &CompoundStatement{Statements: []Operation{
+				&ExtendTransformer{Columns: []*ColumnExpression{{Name: "Title", Operation: EntryExpression("h.Subject")}}},
+				&DropTransformer{Columns: []string{"x-mailer"}},
+			}},
+			Data: mails,
+			Want: [][]string{{"Subject", "Title"}, {"hello", "hello"}},
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			rows := func(d pimtrace.Data) [][]string {
+				var result [][]string
+				for i, r := range d.(tabledata.Data) {
+					if i == 0 {
+						result = append(result, r.HeadersStringArray())
+					}
+					result = append(result, r.StringArray(nil))
+				}
+				return result
+			}
+			got, err := test.Op.Execute(test.Data(), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if diff := cmp.Diff(rows(got), test.Want); diff != "" {
+				t.Errorf("Execute() \n%s", diff)
+			}
+			s, err := ExecuteStream(test.Op, pimtrace.DataStream(test.Data()), nil)
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			if got, err = pimtrace.Collect(s); err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if diff := cmp.Diff(rows(got), test.Want); diff != "" {
+				t.Errorf("ExecuteStream() \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenameTransformer_ExistingColumn(t *testing.T) {
+	op := &RenameTransformer{Renames: []Rename{{From: "From", To: "id"}}}
+	if _, err := op.Execute(sortOrderData(), nil); !errors.Is(err, ErrColumnExists) {
+		t.Errorf("Execute() error = %v, want %v", err, ErrColumnExists)
+	}
+	s, err := ExecuteStream(op, pimtrace.DataStream(sortOrderData()), nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if _, err := pimtrace.Collect(s); !errors.Is(err, ErrColumnExists) {
+		t.Errorf("ExecuteStream() error = %v, want %v", err, ErrColumnExists)
+	}
+	got, err := (&RenameTransformer{Renames: []Rename{{From: "from", To: "FROM"}}}).Execute(sortOrderData()[:1], nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if diff := cmp.Diff(got.Entry(0).(pimtrace.HasStringArray).HeadersStringArray(), []string{"FROM", "count", "id"}); diff != "" {
+		t.Errorf("Execute() renaming to another case \n%s", diff)
+	}
+}
+
+func TestExecuteStream(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Op   Operation
+	}{
+		{Name: "Filter", Op: &FilterStatement{Expression: &evaluator.Query{
+			Expression: &Op{Op: "eq", LHS: EntryExpression("h.numberrange"), RHS: ConstantExpression("4")},
+		}}},
+		{Name: "Filter into table", Op: &CompoundStatement{Statements: []Operation{
+			&FilterStatement{Expression: &evaluator.Query{
+				Expression: &Op{Op: "contains", LHS: EntryExpression("h.name"), RHS: ConstantExpression("a")},
+			}},
+			&TableTransformer{Columns: []*ColumnExpression{{Name: "Name", Operation: EntryExpression("h.name")}}},
+		}}},
+		{Name: "Sort reads everything", Op: &CompoundStatement{Statements: []Operation{
+			&TableTransformer{Columns: []*ColumnExpression{{Name: "Name", Operation: EntryExpression("h.name")}}},
+			&SortTransformer{Expression: []ValueExpression{EntryExpression("c.Name")}},
+		}}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			want, err := test.Op.Execute(LoadData1("testdata/data10.csv"), nil)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			s, err := ExecuteStream(test.Op, pimtrace.DataStream(LoadData1("testdata/data10.csv")), nil)
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			got, err := pimtrace.Collect(s)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("ExecuteStream() \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGroupTransformer_ExecuteKeys(t *testing.T) {
+	header := map[string]int{"a": 0, "b": 1}
+	d := tabledata.Data{
+		{Headers: header, Row: Valueify("x, y", "z")},
+		{Headers: header, Row: Valueify("x", "y, z")},
+		{Headers: header, Row: Valueify("x", "y, z")},
+	}
+	got, err := (&GroupTransformer{Columns: []*ColumnExpression{
+		{Name: "a", Operation: EntryExpression("c.a")},
+		{Name: "b", Operation: EntryExpression("c.b")},
+	}}).Execute(d, nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got.Len() != 2 {
+		t.Fatalf("Execute() made %d groups, want 2", got.Len())
+	}
+	if n := got.(groupdata.Data)[1].Contents.Len(); n != 2 {
+		t.Errorf("second group has %d rows, want 2", n)
+	}
+}
+
+func TestOp_Evaluate(t *testing.T) {
+	d := &mockEntry{vals: map[string]pimtrace.Value{
+		"c.Amount":  pimtrace.SimpleStringValue("150.0"),
+		"c.Date":    pimtrace.SimpleTimeValue(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)),
+		"c.Missing": &pimtrace.SimpleNilValue{},
+		"c.Empty":   pimtrace.SimpleStringValue(""),
+	}}
+	year := &FunctionExpression{Function: "year", Args: []ValueExpression{EntryExpression("c.Date")}}
+	for _, test := range []struct {
+		Name    string
+		Op      evaluator.Expression
+		Want    bool
+		WantErr bool
+	}{
+		{Name: "Numeric equality", Op: &Op{Op: "eq", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("150")}, Want: true},
+		{Name: "Contains", Op: &Op{Op: "contains", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("50.")}, Want: true},
+		{Name: "Unknown operator", Op: &Op{Op: "nope", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("150")}, WantErr: true},
+		{Name: "Not equal", Op: &Op{Op: "ne", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("150")}, Want: false},
+		{Name: "Numeric greater than", Op: &Op{Op: "gt", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("100")}, Want: true},
+		{Name: "Numeric less than", Op: &Op{Op: "lt", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("20")}, Want: false},
+		{Name: "Less or equal", Op: &Op{Op: "le", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("150")}, Want: true},
+		{Name: "Date greater or equal", Op: &Op{Op: "ge", LHS: EntryExpression("c.Date"), RHS: ConstantExpression("2024-03-01")}, Want: true},
+		{Name: "Function operand", Op: &Op{Op: "ge", LHS: year, RHS: ConstantExpression("2020")}, Want: true},
+		{Name: "Missing is never in range", Op: &Op{Op: "lt", LHS: EntryExpression("c.Missing"), RHS: ConstantExpression("100")}, Want: false},
+		{Name: "Empty is never in range", Op: &Op{Op: "ge", LHS: EntryExpression("c.Empty"), RHS: ConstantExpression("20")}, Want: false},
+		{Name: "Missing is not equal", Op: &Op{Op: "ne", LHS: EntryExpression("c.Missing"), RHS: ConstantExpression("100")}, Want: true},
+		{Name: "Between dates", Op: &Between{Value: EntryExpression("c.Date"), Low: ConstantExpression("2024-01-01"), High: ConstantExpression("2024-12-31")}, Want: true},
+		{Name: "Between is inclusive", Op: &Between{Value: EntryExpression("c.Amount"), Low: ConstantExpression("100"), High: ConstantExpression("150")}, Want: true},
+		{Name: "Starts with", Op: &Op{Op: "startswith", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("15")}, Want: true},
+		{Name: "Ends with", Op: &Op{Op: "endswith", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("15")}, Want: false},
+		{Name: "Pattern from an entry", Op: &Op{Op: "matches", LHS: ConstantExpression("150.0"), RHS: EntryExpression("c.Amount")}, Want: true},
+		{Name: "Invalid pattern from an entry", Op: &Op{Op: "matches", LHS: EntryExpression("c.Amount"), RHS: ConstantExpression("(")}, WantErr: true},
+		{Name: "Not between", Op: &Between{Value: year, Low: ConstantExpression("2010"), High: ConstantExpression("2019")}, Want: false},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Op.Evaluate(evaluatorEntryWrapper{Entry: d})
+			if (err != nil) != test.WantErr {
+				t.Fatalf("Evaluate() error = %v, wantErr %v", err, test.WantErr)
+			}
+			if got != test.Want {
+				t.Errorf("Evaluate() = %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestOp_EvaluateMissingColumn(t *testing.T) {
+	row := &tabledata.Row{Headers: map[string]int{"a": 0}, Row: []pimtrace.Value{pimtrace.SimpleStringValue("1")}}
+	for op, want := range map[string]bool{"eq": false, "ne": true} {
+		got, err := (&Op{Op: op, LHS: EntryExpression("c.b"), RHS: ConstantExpression("1")}).Evaluate(evaluatorEntryWrapper{Entry: row})
+		if err != nil || got != want {
+			t.Errorf("%s on a missing column = %v, %v, want %v", op, got, err, want)
+		}
+	}
+}
+
+func TestCompilePattern(t *testing.T) {
+	for _, test := range []struct {
+		Op      string
+		Pattern string
+		Value   string
+		Want    bool
+		WantErr bool
+	}{
+		{Op: "matches", Pattern: `^<.*@example\.com>$`, Value: "<a1@example.com>", Want: true},
+		{Op: "matches", Pattern: `^<.*@example\.com>$`, Value: "<a1@example-com>", Want: false},
+		{Op: "matches", Pattern: "Bob", Value: "bob", Want: false},
+		{Op: "imatches", Pattern: "Bob", Value: "bob", Want: true},
+		{Op: "like", Pattern: "*.pdf", Value: "report.pdf", Want: true},
+		{Op: "like", Pattern: "*.pdf", Value: "report.pdf.exe", Want: false},
+		{Op: "like", Pattern: "a?c", Value: "abc", Want: true},
+		{Op: "like", Pattern: "a?c", Value: "ac", Want: false},
+		{Op: "like", Pattern: "(1+1)*", Value: "(1+1)=2", Want: true},
+		{Op: "matches", Pattern: "(", WantErr: true},
+		{Op: "eq", Pattern: "a", WantErr: true},
+	} {
+		t.Run(test.Op+" "+test.Pattern, func(t *testing.T) {
+			re, err := CompilePattern(test.Op, test.Pattern)
+			if (err != nil) != test.WantErr {
+				t.Fatalf("CompilePattern() error = %v, wantErr %v", err, test.WantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := re.MatchString(test.Value); got != test.Want {
+				t.Errorf("MatchString(%q) = %v, want %v", test.Value, got, test.Want)
+			}
+		})
+	}
+}
+
+func TestMatch_Evaluate(t *testing.T) {
+	d := &mockEntry{vals: map[string]pimtrace.Value{"h.Message-Id": pimtrace.SimpleStringValue("<1@example.com>")}}
+	m, err := NewMatch("matches", EntryExpression("h.Message-Id"), `@example\.com>$`)
+	if err != nil {
+		t.Fatalf("NewMatch() error = %v", err)
+	}
+	if got, err := m.Evaluate(evaluatorEntryWrapper{Entry: d}); err != nil || !got {
+		t.Errorf("Evaluate() = %v, %v, want true", got, err)
+	}
+	if _, err := NewMatch("matches", EntryExpression("h.Message-Id"), "["); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("NewMatch() error = %v, want %v", err, ErrInvalidPattern)
+	}
+}
+
+func TestIn_Evaluate(t *testing.T) {
+	d := &mockEntry{vals: map[string]pimtrace.Value{
+		"h.From":    pimtrace.SimpleStringValue("a@x.com"),
+		"h.To":      pimtrace.SimpleArrayValue{pimtrace.SimpleStringValue("c@z.com"), pimtrace.SimpleStringValue("b@y.com")},
+		"c.Id":      pimtrace.SimpleIntegerValue(2),
+		"c.Missing": &pimtrace.SimpleNilValue{},
+		"c.Price":   pimtrace.SimpleFloatValue(1.5),
+		"c.Text":    pimtrace.SimpleStringValue("02"),
+		"c.Paid":    pimtrace.SimpleBoolValue(true),
+		"c.Whole":   pimtrace.SimpleFloatValue(2),
+		"c.Big":     pimtrace.SimpleIntegerValue(9007199254740992),
+		"c.Bigger":  pimtrace.SimpleIntegerValue(9007199254740993),
+	}}
+	in := func(key string) *In {
+		return NewIn(EntryExpression(key), []string{"a@x.com", "b@y.com", "2", "1.50", "TRUE", "9007199254740993"})
+	}
+	for _, test := range []struct {
+		Key  string
+		Want bool
+	}{
+		{Key: "h.From", Want: true},
+		{Key: "h.To", Want: true},
+		{Key: "c.Id", Want: true},
+		{Key: "c.Missing", Want: false},
+		{Key: "c.Price", Want: true},
+		{Key: "c.Text", Want: true},
+		{Key: "c.Paid", Want: true},
+		{Key: "c.Whole", Want: true},
+		{Key: "c.Big", Want: false},
+		{Key: "c.Bigger", Want: true},
+	} {
+		t.Run(test.Key, func(t *testing.T) {
+			got, err := in(test.Key).Evaluate(evaluatorEntryWrapper{Entry: d})
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != test.Want {
+				t.Errorf("Evaluate() = %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
+
 type mockEntry struct {
 	vals map[string]pimtrace.Value
 }
@@ -492,6 +1112,20 @@ func TestValueExpressions(t *testing.T) {
 	if n := ef.ColumnName(); n != "sum-name" {
 		t.Errorf("EvaluatorFunctionExpression.ColumnName() = %v, want sum-name", n)
 	}
+
+	as := &EvaluatorFunctionExpression{
+		Function: "as",
+		FunctionExpression: evaluator.FunctionExpression{
+			Name: "as",
+			Args: []evaluator.Term{
+				f,
+				ConstantExpression("total"),
+			},
+		},
+	}
+	if n := as.ColumnName(); n != "total" {
+		t.Errorf("EvaluatorFunctionExpression.ColumnName() = %v, want total", n)
+	}
 }
 
 func TestToPimtraceValue(t *testing.T) {
@@ -511,7 +1145,7 @@ func TestToPimtraceValue(t *testing.T) {
 		t.Errorf("toPimtraceValue(int64) failed")
 	}
 
-	if v, _ := toPimtraceValue(float64(42.5)); v.Type() != pimtrace.Integer {
+	if v, _ := toPimtraceValue(float64(42.5)); v.Type() != pimtrace.Float || v.String() != "42.5" {
 		t.Errorf("toPimtraceValue(float64) failed")
 	}
 
@@ -724,3 +1358,246 @@ func TestEntryPathor_Find(t *testing.T) {
 		t.Errorf("Find(nonexistent) should not be nil but Invalidor")
 	}
 }
+
+func TestValidate(t *testing.T) {
+	columns := []string{"Name", "Amount"}
+	count := &FunctionExpression{Function: "count"}
+	tests := []struct {
+		name    string
+		op      Operation
+		columns []string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name: "Known columns and functions",
+			op: &CompoundStatement{Statements: []Operation{
+				&FilterStatement{Expression: &evaluator.Query{Expression: &evaluator.AndExpression{Expressions: []evaluator.Query{
+					{Expression: &evaluator.IsExpression{Field: "Name", Value: "bob"}},
+					{Expression: &Between{Value: EntryExpression("c.Amount"), Low: ConstantExpression("1"), High: ConstantExpression("9")}},
+				}}}},
+				&GroupTransformer{Columns: []*ColumnExpression{{Name: "Name", Operation: EntryExpression("c.Name")}}},
+				&TableTransformer{Columns: []*ColumnExpression{
+					{Name: "Name", Operation: EntryExpression("c.Name")},
+					{Name: "count", Operation: count},
+					{Name: "sum", Operation: &FunctionExpression{Function: "sum", Args: []ValueExpression{EntryExpression("c.Amount")}}},
+				}},
+				&SortTransformer{Expression: []ValueExpression{EntryExpression("c.count"), EntryExpression("sz.")}},
+			}},
+			columns: columns,
+		},
+		{
+			name:    "Unknown column with a suggestion",
+			op:      &FilterStatement{Expression: &evaluator.Query{Expression: &Op{Op: "gt", LHS: EntryExpression("c.amout"), RHS: ConstantExpression("1")}}},
+			columns: columns,
+			wantErr: ErrUnknownColumn,
+			wantMsg: "filter: unknown column: c.amout, did you mean c.Amount?",
+		},
+		{
+			name: "Property prefixes",
+			op: &FilterStatement{Expression: &evaluator.Query{Expression: &evaluator.AndExpression{Expressions: []evaluator.Query{
+				{Expression: &Op{Op: "eq", LHS: EntryExpression("p.Name"), RHS: ConstantExpression("bob")}},
+				{Expression: &Op{Op: "gt", LHS: EntryExpression("property.Amount"), RHS: ConstantExpression("1")}},
+			}}}},
+			columns: columns,
+		},
+		{
+			name:    "Columns in another case are suggested",
+			op:      &FilterStatement{Expression: &evaluator.Query{Expression: &Op{Op: "gt", LHS: EntryExpression("c.AMOUNT"), RHS: ConstantExpression("1")}}},
+			columns: columns,
+			wantErr: ErrUnknownColumn,
+			wantMsg: "filter: unknown column: c.AMOUNT, did you mean c.Amount?",
+		},
+		{
+			name:    "Fields in another case are suggested",
+			op:      &FilterStatement{Expression: &evaluator.Query{Expression: &evaluator.IsExpression{Field: "amount", Value: "1"}}},
+			columns: columns,
+			wantErr: ErrUnknownColumn,
+			wantMsg: "filter: unknown column: amount, did you mean Amount?",
+		},
+		{
+			name:    "Fields with a prefix",
+			op:      &FilterStatement{Expression: &evaluator.Query{Expression: &evaluator.IsExpression{Field: "c.Amount", Value: "1"}}},
+			columns: columns,
+		},
+		{
+			name:    "Unknown property",
+			op:      &FilterStatement{Expression: &evaluator.Query{Expression: &Op{Op: "eq", LHS: EntryExpression("p.Nam"), RHS: ConstantExpression("bob")}}},
+			columns: columns,
+			wantErr: ErrUnknownColumn,
+			wantMsg: "filter: unknown column: p.Nam, did you mean p.Name?",
+		},
+		{
+			name:    "Columns are unknown",
+			op:      &FilterStatement{Expression: &evaluator.Query{Expression: &Op{Op: "gt", LHS: EntryExpression("h.anything"), RHS: ConstantExpression("1")}}},
+			columns: nil,
+		},
+		{
+			name: "Columns come from the table",
+			op: &CompoundStatement{Statements: []Operation{
+				&TableTransformer{Columns: []*ColumnExpression{{Name: "count", Operation: count}}},
+				&SortTransformer{Expression: []ValueExpression{EntryExpression("h.Name")}},
+			}},
+			wantErr: ErrUnknownColumn,
+			wantMsg: "sort: unknown column: h.Name",
+		},
+		{
+			name:    "Unknown function with a suggestion",
+			op:      &TableTransformer{Columns: []*ColumnExpression{{Name: "x", Operation: &FunctionExpression{Function: "yeer"}}}},
+			wantErr: ErrUnknownFunction,
+			wantMsg: "table: unknown function: f.yeer, did you mean f.year?",
+		},
+		{
+			name: "Wrong number of arguments",
+			op: &TableTransformer{Columns: []*ColumnExpression{{Name: "x", Operation: &EvaluatorFunctionExpression{
+				Function:           "as",
+				FunctionExpression: evaluator.FunctionExpression{Name: "as", Args: []evaluator.Term{EntryExpression("c.Name")}},
+			}}}},
+			wantErr: ErrArgumentCount,
+			wantMsg: "table: wrong number of arguments: f.as given 1, expected f.as[Any,String]",
+		},
+		{
+			name: "Variadic arguments",
+			op: &TableTransformer{Columns: []*ColumnExpression{{Name: "x", Operation: &FunctionExpression{
+				Function: "or",
+				Args:     []ValueExpression{EntryExpression("c.Name"), EntryExpression("c.Amount"), EntryExpression("c.Name")},
+			}}}},
+			columns: columns,
+		},
+		{
+			name: "Extend, drop and rename change the columns",
+			op: &CompoundStatement{Statements: []Operation{
+				&ExtendTransformer{Columns: []*ColumnExpression{{Name: "Total", Operation: EntryExpression("c.Amount")}}},
+				&DropTransformer{Columns: []string{"Amount"}},
+				&RenameTransformer{Renames: []Rename{{From: "Total", To: "Sum"}}},
+				&SortTransformer{Expression: []ValueExpression{EntryExpression("c.Sum"), EntryExpression("c.Amount")}},
+			}},
+			columns: columns,
+			wantErr: ErrUnknownColumn,
+			wantMsg: "sort: unknown column: c.Amount",
+		},
+		{
+			name:    "Rename onto an existing column",
+			op:      &RenameTransformer{Renames: []Rename{{From: "Name", To: "amount"}}},
+			columns: columns,
+			wantErr: ErrColumnExists,
+			wantMsg: "rename Name: column already exists: amount",
+		},
+		{
+			name:    "Drop an unknown column",
+			op:      &DropTransformer{Columns: []string{"Amout"}},
+			columns: columns,
+			wantErr: ErrUnknownColumn,
+			wantMsg: "drop: unknown column: c.Amout, did you mean c.Amount?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.op, tt.columns)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantMsg {
+				t.Errorf("Validate() error = %q, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	op := &CompoundStatement{Statements: []Operation{
+		&FilterStatement{Expression: &evaluator.Query{Expression: &evaluator.OrExpression{Expressions: []evaluator.Query{
+			{Expression: &evaluator.IsExpression{Field: "From", Value: "bob"}},
+			{Expression: &evaluator.NotExpression{Expression: evaluator.Query{
+				Expression: NewIn(EntryExpression("h.To"), []string{"b", "a"}),
+			}}},
+		}}}},
+		&GroupTransformer{Columns: []*ColumnExpression{{Name: "From", Operation: EntryExpression("h.From")}}},
+		&TableTransformer{Columns: []*ColumnExpression{
+			{Name: "From", Operation: EntryExpression("c.From")},
+			{Name: "sum-Size", Operation: &FunctionExpression{Function: "sum", Args: []ValueExpression{EntryExpression("h.Size")}}},
+		}},
+		&SortTransformer{Expression: []ValueExpression{EntryExpression("c.sum-Size"), ConstantExpression("x y")}},
+		&OffsetTransformer{N: 1},
+		&TopTransformer{N: 5, Sort: &SortTransformer{Expression: []ValueExpression{EntryExpression("c.From")}, Order: []SortOrder{{Descending: true}}}},
+		&LimitTransformer{N: 2},
+	}}
+	b := &bytes.Buffer{}
+	if err := Explain(b, op, Shape{Type: "mail"}); err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	want := `1. filter mail entries keeping those where
+   or
+     From eq "bob"
+     not
+       h.To in "b", "a"
+2. into summary, one row per distinct group of mail entries by
+   From = h.From
+3. into table, one row per summary entry with the columns
+   From = c.From
+   sum-Size = f.sum[h.Size]
+4. sort table entries by
+   c.sum-Size asc
+   "x y" asc
+5. skip the first 1 table entries
+6. keep the top 5 table entries by
+   c.From desc
+7. keep the first 2 table entries
+Output: table with columns From, sum-Size
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("Explain() %s", diff)
+	}
+	b.Reset()
+	if err := Explain(b, nil, Shape{Type: "table"}); err != nil || b.String() != "Nothing to do, the input is written as it is\nOutput: table\n" {
+		t.Errorf("Explain() = %q, %v", b.String(), err)
+	}
+}
+
+func TestExplain_UnexplainedStep(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := Explain(b, &mockErrOp{}, Shape{Type: "mail"}); err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	want := "1. a step which can't be explained, its output is unknown\nOutput: unknown\n"
+	if b.String() != want {
+		t.Errorf("Explain() = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	op := &FilterStatement{Expression: &evaluator.Query{Expression: &evaluator.OrExpression{Expressions: []evaluator.Query{
+		{Expression: &mockCondition{}},
+		{Expression: &Op{Op: "eq", LHS: &mockTerm{}, RHS: ConstantExpression("1")}},
+	}}}}
+	if err := Explain(b, op, Shape{Type: "mail"}); err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	for _, want := range []string{"a condition which can't be explained", "a value which can't be explained eq \"1\""} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Explain() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+	if strings.Contains(b.String(), "mock") {
+		t.Errorf("Explain() = %q, want no Go type names", b.String())
+	}
+}
+
+type mockCondition struct{}
+
+func (m *mockCondition) Evaluate(d interface{}, opts ...any) (bool, error) {
+	return true, nil
+}
+
+type mockTerm struct{}
+
+func (m *mockTerm) Execute(d pimtrace.Entry, ctx *evaluator.Context) (pimtrace.Value, error) {
+	return pimtrace.SimpleStringValue("1"), nil
+}
+
+func (m *mockTerm) ColumnName() string {
+	return "mock"
+}
+
+func (m *mockTerm) Evaluate(d interface{}, opts ...any) (interface{}, error) {
+	return "1", nil
+}
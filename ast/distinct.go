@@ -0,0 +1,82 @@
+package ast
+
+import (
+	"fmt"
+	"pimtrace"
+
+	"github.com/arran4/go-evaluator"
+)
+
+var (
+	ErrNoDistinctKey = fmt.Errorf("entry can't be compared whole, use distinct by")
+)
+
+// DistinctTransformer keeps the first entry for each distinct value of Expression, or if there is no Expression the
+// first of each entry with identical headers, properties or columns. Mail is compared by its headers, not its body.
+type DistinctTransformer struct {
+	Expression []ValueExpression
+	// Dropped is the number of duplicates dropped by the last run
+	Dropped int
+}
+
+func (t *DistinctTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	seen := map[string]struct{}{}
+	t.Dropped = 0
+	result := d.NewSelf()
+	for i := 0; i < d.Len(); i++ {
+		e := d.Entry(i)
+		first, err := t.first(seen, e, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			result = result.SetEntry(result.Len(), e)
+		}
+	}
+	return result, nil
+}
+
+var _ Operation = (*DistinctTransformer)(nil)
+
+// first records the key of e in seen, returning false and counting it as dropped if it was already there.
+func (t *DistinctTransformer) first(seen map[string]struct{}, e pimtrace.Entry, ctx *evaluator.Context) (bool, error) {
+	key, err := t.key(e, ctx)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := seen[key]; ok {
+		t.Dropped++
+		return false, nil
+	}
+	seen[key] = struct{}{}
+	return true, nil
+}
+
+func (t *DistinctTransformer) key(e pimtrace.Entry, ctx *evaluator.Context) (string, error) {
+	var r pimtrace.SimpleArrayValue
+	if len(t.Expression) == 0 {
+		sa, ok := e.(pimtrace.HasStringArray)
+		if !ok {
+			return "", fmt.Errorf("distinct: %w: %T", ErrNoDistinctKey, e)
+		}
+		headers := sa.HeadersStringArray()
+		for i, v := range sa.StringArray(headers) {
+			r = append(r, pimtrace.SimpleStringValue(headers[i]), pimtrace.SimpleStringValue(v))
+		}
+		return pimtrace.Key(r), nil
+	}
+	for _, ex := range t.Expression {
+		v, err := ex.Execute(e, ctx)
+		if err != nil {
+			return "", fmt.Errorf("distinct: %w", err)
+		}
+		r = append(r, v)
+	}
+	return pimtrace.Key(r), nil
+}
+
+func (t *DistinctTransformer) Report(logf func(format string, args ...any)) {
+	logf("distinct: dropped %d duplicates", t.Dropped)
+}
+
+var _ ReportingOperation = (*DistinctTransformer)(nil)
@@ -0,0 +1,302 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+)
+
+// Shape is the type of the entries an operation outputs, such as mail, table or summary, and their columns if known.
+type Shape struct {
+	Type    string
+	Columns []string
+}
+
+func (s Shape) String() string {
+	if s.Columns == nil {
+		return s.Type
+	}
+	return fmt.Sprintf("%s with columns %s", s.Type, strings.Join(s.Columns, ", "))
+}
+
+// ExplainingOperation is an Operation which can describe what it does for Explain.
+type ExplainingOperation interface {
+	Operation
+	// Explain writes the steps of the operation to p and returns the shape of its output for input in.
+	Explain(p *Plan, in Shape) Shape
+}
+
+// StepDescriber is an Operation from outside this package, such as an output format, which describes its step for
+// Explain and keeps the shape of its input.
+type StepDescriber interface {
+	Operation
+	DescribeStep(entries string) string
+}
+
+// Plan is the numbered list of steps written by Explain.
+type Plan struct {
+	w     io.Writer
+	steps int
+	err   error
+}
+
+// Step starts the next step of the plan.
+func (p *Plan) Step(format string, args ...any) {
+	p.steps++
+	p.printf("%d. %s\n", p.steps, fmt.Sprintf(format, args...))
+}
+
+// Detail describes the current step, depth nests details.
+func (p *Plan) Detail(depth int, format string, args ...any) {
+	p.printf("   %s%s\n", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+func (p *Plan) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// Explain writes how op will run over entries of the shape in without running it.
+func Explain(w io.Writer, op Operation, in Shape) error {
+	p := &Plan{w: w}
+	out := explain(p, op, in)
+	if p.steps == 0 {
+		p.printf("Nothing to do, the input is written as it is\n")
+	}
+	p.printf("Output: %s\n", out)
+	return p.err
+}
+
+func explain(p *Plan, op Operation, in Shape) Shape {
+	if op == nil {
+		return in
+	}
+	if eop, ok := op.(ExplainingOperation); ok {
+		return eop.Explain(p, in)
+	}
+	if sd, ok := op.(StepDescriber); ok {
+		p.Step("%s", sd.DescribeStep(in.Type))
+		return in
+	}
+	p.Step("a step which can't be explained, its output is unknown")
+	return Shape{Type: "unknown"}
+}
+
+func (o *CompoundStatement) Explain(p *Plan, in Shape) Shape {
+	for _, op := range o.Statements {
+		in = explain(p, op, in)
+	}
+	return in
+}
+
+var _ ExplainingOperation = (*CompoundStatement)(nil)
+
+func (f FilterStatement) Explain(p *Plan, in Shape) Shape {
+	p.Step("filter %s entries keeping those where", in.Type)
+	if f.Expression != nil {
+		explainCondition(p, 0, f.Expression.Expression)
+	}
+	return in
+}
+
+var _ ExplainingOperation = (*FilterStatement)(nil)
+
+func (t *TableTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("into table, one row per %s entry with the columns", in.Type)
+	return Shape{Type: "table", Columns: explainColumns(p, t.Columns)}
+}
+
+var _ ExplainingOperation = (*TableTransformer)(nil)
+
+func (s *SortTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("sort %s entries by", in.Type)
+	for i, e := range s.Expression {
+		p.Detail(0, "%s %s", Describe(e), s.order(i))
+	}
+	return in
+}
+
+var _ ExplainingOperation = (*SortTransformer)(nil)
+
+func (l *LimitTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("keep the first %d %s entries", l.N, in.Type)
+	return in
+}
+
+var _ ExplainingOperation = (*LimitTransformer)(nil)
+
+func (o *OffsetTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("skip the first %d %s entries", o.N, in.Type)
+	return in
+}
+
+var _ ExplainingOperation = (*OffsetTransformer)(nil)
+
+func (t *TopTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("keep the top %d %s entries by", t.N, in.Type)
+	for i, e := range t.Sort.Expression {
+		p.Detail(0, "%s %s", Describe(e), t.Sort.order(i))
+	}
+	return in
+}
+
+var _ ExplainingOperation = (*TopTransformer)(nil)
+
+func (t *DistinctTransformer) Explain(p *Plan, in Shape) Shape {
+	if len(t.Expression) == 0 {
+		p.Step("keep the first of each identical %s entry", in.Type)
+		return in
+	}
+	p.Step("keep the first %s entry for each distinct", in.Type)
+	for _, e := range t.Expression {
+		p.Detail(0, "%s", Describe(e))
+	}
+	return in
+}
+
+var _ ExplainingOperation = (*DistinctTransformer)(nil)
+
+func (t *ExtendTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("extend, one row per %s entry with all its fields and the columns", in.Type)
+	columns := explainColumns(p, t.Columns)
+	if in.Columns == nil {
+		return Shape{Type: "table"}
+	}
+	out := Shape{Type: "table", Columns: append([]string{}, in.Columns...)}
+	for _, c := range columns {
+		if !containsString(out.Columns, c) {
+			out.Columns = append(out.Columns, c)
+		}
+	}
+	return out
+}
+
+var _ ExplainingOperation = (*ExtendTransformer)(nil)
+
+func (t *DropTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("drop, one row per %s entry without the columns %s", in.Type, strings.Join(t.Columns, ", "))
+	if in.Columns == nil {
+		return Shape{Type: "table"}
+	}
+	out := Shape{Type: "table"}
+	for _, c := range in.Columns {
+		if indexOf(t.Columns, c) < 0 {
+			out.Columns = append(out.Columns, c)
+		}
+	}
+	return out
+}
+
+var _ ExplainingOperation = (*DropTransformer)(nil)
+
+func (t *RenameTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("rename, one row per %s entry with the columns", in.Type)
+	var columns []string
+	if in.Columns != nil {
+		columns = append([]string{}, in.Columns...)
+	}
+	for _, r := range t.Renames {
+		p.Detail(0, "%s to %s", r.From, r.To)
+		if i := indexOf(columns, r.From); i >= 0 {
+			columns[i] = r.To
+		}
+	}
+	return Shape{Type: "table", Columns: columns}
+}
+
+var _ ExplainingOperation = (*RenameTransformer)(nil)
+
+func (g *GroupTransformer) Explain(p *Plan, in Shape) Shape {
+	p.Step("into summary, one row per distinct group of %s entries by", in.Type)
+	return Shape{Type: "summary", Columns: explainColumns(p, g.Columns)}
+}
+
+var _ ExplainingOperation = (*GroupTransformer)(nil)
+
+func explainColumns(p *Plan, columns []*ColumnExpression) []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		p.Detail(0, "%s = %s", c.Name, Describe(c.Operation))
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func explainCondition(p *Plan, depth int, e evaluator.Expression) {
+	switch e := e.(type) {
+	case *evaluator.NotExpression:
+		p.Detail(depth, "not")
+		explainCondition(p, depth+1, e.Expression.Expression)
+	case *evaluator.AndExpression:
+		p.Detail(depth, "and")
+		for _, q := range e.Expressions {
+			explainCondition(p, depth+1, q.Expression)
+		}
+	case *evaluator.OrExpression:
+		p.Detail(depth, "or")
+		for _, q := range e.Expressions {
+			explainCondition(p, depth+1, q.Expression)
+		}
+	case *evaluator.IsExpression:
+		p.Detail(depth, "%s eq %s", e.Field, describeConstant(e.Value))
+	case *evaluator.ContainsExpression:
+		p.Detail(depth, "%s contains %s", e.Field, describeConstant(e.Value))
+	case *evaluator.IContainsExpression:
+		p.Detail(depth, "%s icontains %s", e.Field, describeConstant(e.Value))
+	case *Op:
+		p.Detail(depth, "%s %s %s", Describe(e.LHS), e.Op, Describe(e.RHS))
+	case *Between:
+		p.Detail(depth, "%s between %s and %s", Describe(e.Value), Describe(e.Low), Describe(e.High))
+	case *Match:
+		p.Detail(depth, "%s %s regexp %s", Describe(e.Value), e.Op, strconv.Quote(e.Pattern.String()))
+	case *In:
+		items := make([]string, 0, len(e.Items))
+		for _, item := range e.Items {
+			items = append(items, strconv.Quote(item))
+		}
+		p.Detail(depth, "%s in %s", Describe(e.Value), strings.Join(items, ", "))
+	default:
+		p.Detail(depth, "a condition which can't be explained")
+	}
+}
+
+func describeConstant(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// Describe writes e the way it would be written in a query, with constants quoted.
+func Describe(e evaluator.Term) string {
+	switch e := e.(type) {
+	case ConstantExpression:
+		return strconv.Quote(string(e))
+	case EntryExpression:
+		return string(e)
+	case *FunctionExpression:
+		args := make([]evaluator.Term, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, arg)
+		}
+		return describeFunction(e.Function, args)
+	case *EvaluatorFunctionExpression:
+		return describeFunction(e.Function, e.Args)
+	}
+	return "a value which can't be explained"
+}
+
+func describeFunction(name string, args []evaluator.Term) string {
+	if len(args) == 0 {
+		return "f." + name
+	}
+	ss := make([]string, 0, len(args))
+	for _, arg := range args {
+		ss = append(ss, Describe(arg))
+	}
+	return fmt.Sprintf("f.%s[%s]", name, strings.Join(ss, ","))
+}
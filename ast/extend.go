@@ -0,0 +1,176 @@
+package ast
+
+import (
+	"fmt"
+	"pimtrace"
+	"pimtrace/dataformats/tabledata"
+	"slices"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+)
+
+var (
+	ErrColumnExists = fmt.Errorf("column already exists")
+)
+
+// ExtendTransformer turns entries into table rows keeping all their fields and adds Columns, or replaces the fields
+// with the same name.
+type ExtendTransformer struct {
+	Columns []*ColumnExpression
+}
+
+func (t *ExtendTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	return executeRows(d, ctx, t.row)
+}
+
+func (t *ExtendTransformer) row(e pimtrace.Entry, ctx *evaluator.Context) (*tabledata.Row, error) {
+	headers, values, err := fields(e)
+	if err != nil {
+		return nil, fmt.Errorf("extend: %w", err)
+	}
+	for _, c := range t.Columns {
+		v, err := c.Operation.Execute(e, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("extend %s: %w", c.Name, err)
+		}
+		if i := slices.Index(headers, c.Name); i >= 0 {
+			values[i] = v
+			continue
+		}
+		headers = append(headers, c.Name)
+		values = append(values, v)
+	}
+	return newRow(headers, values), nil
+}
+
+var _ Operation = (*ExtendTransformer)(nil)
+
+// DropTransformer turns entries into table rows without the fields named Columns.
+type DropTransformer struct {
+	Columns []string
+}
+
+func (t *DropTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	return executeRows(d, ctx, t.row)
+}
+
+func (t *DropTransformer) row(e pimtrace.Entry, ctx *evaluator.Context) (*tabledata.Row, error) {
+	headers, values, err := fields(e)
+	if err != nil {
+		return nil, fmt.Errorf("drop: %w", err)
+	}
+	keepHeaders, keepValues := headers[:0], values[:0]
+	for i, h := range headers {
+		if indexOf(t.Columns, h) < 0 {
+			keepHeaders, keepValues = append(keepHeaders, h), append(keepValues, values[i])
+		}
+	}
+	return newRow(keepHeaders, keepValues), nil
+}
+
+var _ Operation = (*DropTransformer)(nil)
+
+// Rename is a field to rename and its new name.
+type Rename struct {
+	From string
+	To   string
+}
+
+// RenameTransformer turns entries into table rows with their fields renamed. Renaming a field to the name of another
+// field is an error rather than replacing it.
+type RenameTransformer struct {
+	Renames []Rename
+}
+
+func (t *RenameTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	return executeRows(d, ctx, t.row)
+}
+
+func (t *RenameTransformer) row(e pimtrace.Entry, ctx *evaluator.Context) (*tabledata.Row, error) {
+	headers, values, err := fields(e)
+	if err != nil {
+		return nil, fmt.Errorf("rename: %w", err)
+	}
+	for _, r := range t.Renames {
+		i := indexOf(headers, r.From)
+		if i < 0 {
+			continue
+		}
+		if j := indexOf(headers, r.To); j >= 0 && j != i {
+			return nil, fmt.Errorf("rename %s: %w: %s", r.From, ErrColumnExists, r.To)
+		}
+		headers[i] = r.To
+	}
+	return newRow(headers, values), nil
+}
+
+var _ Operation = (*RenameTransformer)(nil)
+
+type rowFunc func(e pimtrace.Entry, ctx *evaluator.Context) (*tabledata.Row, error)
+
+func executeRows(d pimtrace.Data, ctx *evaluator.Context, row rowFunc) (pimtrace.Data, error) {
+	result := make(tabledata.Data, 0, d.Len())
+	for i := 0; i < d.Len(); i++ {
+		r, err := row(d.Entry(i), ctx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// fields returns the field names and values of e. Table rows keep their values, other entries use Get and fall back to
+// the text of the field.
+func fields(e pimtrace.Entry) ([]string, []pimtrace.Value, error) {
+	if r, ok := e.(*tabledata.Row); ok {
+		headers := r.HeadersStringArray()
+		values := make([]pimtrace.Value, len(headers))
+		copy(values, r.Row)
+		for i, v := range values {
+			if v == nil {
+				values[i] = &pimtrace.SimpleNilValue{}
+			}
+		}
+		return headers, values, nil
+	}
+	sa, ok := e.(pimtrace.HasStringArray)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T", tabledata.ErrNotTabular, e)
+	}
+	headers := sa.HeadersStringArray()
+	ss := sa.StringArray(headers)
+	values := make([]pimtrace.Value, len(headers))
+	for i, h := range headers {
+		if v, err := e.Get(h); err == nil && v != nil {
+			values[i] = v
+		} else {
+			values[i] = pimtrace.SimpleStringValue(ss[i])
+		}
+	}
+	return headers, values, nil
+}
+
+func newRow(headers []string, values []pimtrace.Value) *tabledata.Row {
+	m := make(map[string]int, len(headers))
+	for i, h := range headers {
+		m[h] = i
+	}
+	return &tabledata.Row{Headers: m, Row: values}
+}
+
+// indexOf finds name in headers, ignoring case if there is no exact match as mail headers are case insensitive.
+func indexOf(headers []string, name string) int {
+	for i, h := range headers {
+		if h == name {
+			return i
+		}
+	}
+	for i, h := range headers {
+		if strings.EqualFold(h, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+)
+
+// Having returns q for filtering the rows of a summary before its calculated columns are added. References to the
+// calculated columns, by name or by their f.as name, are replaced with the calculation so `having c.count gt .5` and
+// `having f.count gt .5` are the same.
+func Having(q *evaluator.Query, calculated []*ColumnExpression) *evaluator.Query {
+	if q == nil {
+		return nil
+	}
+	h := having(calculated)
+	return &evaluator.Query{Expression: h.condition(q.Expression)}
+}
+
+type having []*ColumnExpression
+
+func (h having) calculation(e EntryExpression) (ValueExpression, bool) {
+	prefix, name, _ := strings.Cut(string(e), ".")
+	switch prefix {
+	case "c", "column":
+		return h.named(name)
+	}
+	return nil, false
+}
+
+func (h having) named(name string) (ValueExpression, bool) {
+	for _, c := range h {
+		if c.Name == name {
+			return unalias(c.Operation), true
+		}
+	}
+	return nil, false
+}
+
+// unalias returns what f.as names, as the name isn't needed to filter by it.
+func unalias(e ValueExpression) ValueExpression {
+	switch e := e.(type) {
+	case *FunctionExpression:
+		if e.Function == "as" && len(e.Args) > 0 {
+			return e.Args[0]
+		}
+	case *EvaluatorFunctionExpression:
+		if e.Function == "as" && len(e.Args) > 0 {
+			if v, ok := e.Args[0].(ValueExpression); ok {
+				return v
+			}
+		}
+	}
+	return e
+}
+
+func (h having) value(e ValueExpression) ValueExpression {
+	switch e := e.(type) {
+	case EntryExpression:
+		if v, ok := h.calculation(e); ok {
+			return v
+		}
+	case *FunctionExpression:
+		var args []ValueExpression
+		for _, arg := range e.Args {
+			args = append(args, h.value(arg))
+		}
+		return &FunctionExpression{Function: e.Function, Args: args, F: e.F}
+	case *EvaluatorFunctionExpression:
+		var args []evaluator.Term
+		for _, arg := range e.Args {
+			if v, ok := arg.(ValueExpression); ok {
+				arg = h.value(v)
+			}
+			args = append(args, arg)
+		}
+		r := *e
+		r.Args = args
+		return &r
+	}
+	return e
+}
+
+func (h having) condition(e evaluator.Expression) evaluator.Expression {
+	switch e := e.(type) {
+	case *evaluator.NotExpression:
+		return &evaluator.NotExpression{Expression: evaluator.Query{Expression: h.condition(e.Expression.Expression)}}
+	case *evaluator.AndExpression:
+		return &evaluator.AndExpression{Expressions: h.conditions(e.Expressions)}
+	case *evaluator.OrExpression:
+		return &evaluator.OrExpression{Expressions: h.conditions(e.Expressions)}
+	case *evaluator.IsExpression:
+		return h.field("eq", e.Field, e.Value, e)
+	case *evaluator.ContainsExpression:
+		return h.field("contains", e.Field, e.Value, e)
+	case *evaluator.IContainsExpression:
+		return h.field("icontains", e.Field, e.Value, e)
+	case *Op:
+		return &Op{Op: e.Op, LHS: h.value(e.LHS), RHS: h.value(e.RHS)}
+	case *Between:
+		return &Between{Value: h.value(e.Value), Low: h.value(e.Low), High: h.value(e.High)}
+	case *Match:
+		return &Match{Op: e.Op, Value: h.value(e.Value), Pattern: e.Pattern}
+	case *In:
+		return &In{Value: h.value(e.Value), Items: e.Items, Set: e.Set}
+	}
+	return e
+}
+
+func (h having) conditions(qs []evaluator.Query) []evaluator.Query {
+	result := make([]evaluator.Query, 0, len(qs))
+	for _, q := range qs {
+		result = append(result, evaluator.Query{Expression: h.condition(q.Expression)})
+	}
+	return result
+}
+
+// field replaces the evaluator's field lookup of a calculated column with an Op on its calculation.
+func (h having) field(op string, field string, value interface{}, e evaluator.Expression) evaluator.Expression {
+	v, ok := h.named(field)
+	s, isString := value.(string)
+	if !ok || !isString {
+		return e
+	}
+	return &Op{Op: op, LHS: v, RHS: ConstantExpression(s)}
+}
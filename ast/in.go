@@ -0,0 +1,99 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"pimtrace"
+	"strconv"
+	"strings"
+)
+
+// In is true when Value is one of Items, compared as eq compares them so `.1.50` matches a Float 1.5. A multi-value
+// Value is in the set when any of its values are.
+type In struct {
+	Value ValueExpression
+	Items []string
+	// Set holds the keys of Items, see inKeys
+	Set map[string]struct{}
+}
+
+func NewIn(value ValueExpression, items []string) *In {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		for _, k := range inKeys(pimtrace.SimpleStringValue(item), true) {
+			set[k] = struct{}{}
+		}
+	}
+	return &In{Value: value, Items: items, Set: set}
+}
+
+func (e *In) Evaluate(d interface{}, opts ...any) (bool, error) {
+	if e.Value == nil {
+		return false, fmt.Errorf("missing operands")
+	}
+	vs, err := operandValues(d, opts, e.Value)
+	if err != nil {
+		return false, err
+	}
+	return e.contains(vs[0]), nil
+}
+
+func (e *In) contains(v pimtrace.Value) bool {
+	switch v.Type() {
+	case pimtrace.Nil:
+		return false
+	case pimtrace.Array:
+		for _, v := range v.Array() {
+			if v != nil && e.contains(v) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, k := range inKeys(v, false) {
+		if _, ok := e.Set[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inKeys returns keys which are the same for values pimtrace.Compare finds equal. An item of the list is text which
+// could be compared as a number, a time or a bool depending on the value, so it has a key for each it can be read as.
+func inKeys(v pimtrace.Value, item bool) []string {
+	var keys []string
+	if n := pimtrace.NumericValue(v); n != nil {
+		keys = append(keys, numberKey(n))
+		if !item {
+			return keys
+		}
+	}
+	switch {
+	case v.Type() == pimtrace.Time:
+		return append(keys, "t"+strconv.FormatInt(v.Time().UnixNano(), 10))
+	case v.Type() == pimtrace.Bool:
+		return append(keys, "b"+v.String())
+	case !item:
+		return append(keys, "s"+v.String())
+	}
+	if t := pimtrace.ParseTime(v.String()); t != nil {
+		keys = append(keys, "t"+strconv.FormatInt(t.UnixNano(), 10))
+	}
+	switch strings.ToLower(v.String()) {
+	case "true", "false":
+		keys = append(keys, "b"+strings.ToLower(v.String()))
+	}
+	return append(keys, "s"+v.String())
+}
+
+// numberKey keys whole numbers as integers, as a float64 can't tell integers above 2^53 apart.
+func numberKey(n pimtrace.Value) string {
+	if i, ok := n.(pimtrace.SimpleIntegerValue); ok {
+		return "n" + strconv.FormatInt(int64(i), 10)
+	}
+	f := *n.Float64()
+	if f == math.Trunc(f) && math.Abs(f) < math.MaxInt64 {
+		return "n" + strconv.FormatInt(int64(f), 10)
+	}
+	return "n" + strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,135 @@
+package ast
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"pimtrace"
+	"sort"
+
+	"github.com/arran4/go-evaluator"
+)
+
+// LimitTransformer keeps the first N entries.
+type LimitTransformer struct {
+	N int
+}
+
+func (l *LimitTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	if d.Len() <= l.N {
+		return d, nil
+	}
+	return d.Truncate(l.N), nil
+}
+
+var _ Operation = (*LimitTransformer)(nil)
+
+// OffsetTransformer skips the first N entries.
+type OffsetTransformer struct {
+	N int
+}
+
+func (o *OffsetTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	result := d.NewSelf()
+	for i := o.N; i < d.Len(); i++ {
+		result = result.SetEntry(result.Len(), d.Entry(i))
+	}
+	return result, nil
+}
+
+var _ Operation = (*OffsetTransformer)(nil)
+
+// TopTransformer keeps the first N entries in the order of Sort, without sorting every entry.
+type TopTransformer struct {
+	N    int
+	Sort *SortTransformer
+}
+
+func (t *TopTransformer) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.Data, error) {
+	return t.top(pimtrace.DataStream(d), ctx)
+}
+
+func (t *TopTransformer) top(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Data, error) {
+	h := &topHeap{sort: t.Sort}
+	for pos := 0; ; pos++ {
+		e, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t.N > 0 {
+			h.add(&topEntry{entry: e, keys: t.Sort.keys(e, ctx), pos: pos}, t.N)
+		}
+	}
+	d := s.NewSelf()
+	for _, e := range h.sorted() {
+		d = d.SetEntry(d.Len(), e.entry)
+	}
+	return d, nil
+}
+
+var _ Operation = (*TopTransformer)(nil)
+
+type topEntry struct {
+	entry pimtrace.Entry
+	keys  []pimtrace.Value
+	// pos is the input position, so entries with equal keys keep their input order
+	pos int
+}
+
+// topHeap holds the best entries seen so far with the worst on top, so it is the one replaced by a better entry.
+type topHeap struct {
+	sort    *SortTransformer
+	entries []*topEntry
+}
+
+func (h *topHeap) before(a, b *topEntry) bool {
+	if c := h.sort.compare(a.keys, b.keys); c != 0 {
+		return c < 0
+	}
+	return a.pos < b.pos
+}
+
+func (h *topHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *topHeap) Less(i, j int) bool {
+	return h.before(h.entries[j], h.entries[i])
+}
+
+func (h *topHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *topHeap) Push(x any) {
+	h.entries = append(h.entries, x.(*topEntry))
+}
+
+func (h *topHeap) Pop() any {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return e
+}
+
+// add keeps e if it is one of the best n entries seen so far.
+func (h *topHeap) add(e *topEntry, n int) {
+	if h.Len() < n {
+		heap.Push(h, e)
+		return
+	}
+	if h.before(e, h.entries[0]) {
+		h.entries[0] = e
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted returns the kept entries from best to worst.
+func (h *topHeap) sorted() []*topEntry {
+	sort.Slice(h.entries, func(i, j int) bool {
+		return h.before(h.entries[i], h.entries[j])
+	})
+	return h.entries
+}
@@ -0,0 +1,115 @@
+package ast
+
+import (
+	"fmt"
+	"pimtrace"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidPattern = fmt.Errorf("invalid pattern")
+)
+
+// IsPatternOp reports whether the right hand side of op is a pattern. Constant patterns are compiled once into a Match,
+// otherwise the pattern is compiled for each entry.
+func IsPatternOp(op string) bool {
+	switch op {
+	case "matches", "imatches", "like":
+		return true
+	}
+	return false
+}
+
+// CompilePattern compiles pattern for op: `matches` is RE2, `imatches` is case-insensitive RE2 and `like` is a glob
+// where `*` matches any run of characters and `?` any single character.
+func CompilePattern(op string, pattern string) (*regexp.Regexp, error) {
+	var expr string
+	switch op {
+	case "matches":
+		expr = pattern
+	case "imatches":
+		expr = "(?i)" + pattern
+	case "like":
+		expr = globToRegexp(pattern)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOperator, op)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %q: %s", ErrInvalidPattern, op, pattern, err)
+	}
+	return re, nil
+}
+
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return b.String()
+}
+
+func patternOp(op string) OpFunc {
+	return func(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+		re, err := CompilePattern(op, lhsv.String())
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(rhsv.String()), nil
+	}
+}
+
+var (
+	MatchesOp  = patternOp("matches")
+	IMatchesOp = patternOp("imatches")
+	LikeOp     = patternOp("like")
+)
+
+func StartsWithOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	return strings.HasPrefix(rhsv.String(), lhsv.String()), nil
+}
+
+var _ OpFunc = StartsWithOp
+
+func EndsWithOp(rhsv pimtrace.Value, lhsv pimtrace.Value) (bool, error) {
+	return strings.HasSuffix(rhsv.String(), lhsv.String()), nil
+}
+
+var _ OpFunc = EndsWithOp
+
+// Match is an Op against a pattern compiled when the query was parsed.
+type Match struct {
+	Op      string
+	Value   ValueExpression
+	Pattern *regexp.Regexp
+}
+
+// NewMatch compiles pattern for op, see CompilePattern.
+func NewMatch(op string, value ValueExpression, pattern string) (*Match, error) {
+	re, err := CompilePattern(op, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &Match{Op: op, Value: value, Pattern: re}, nil
+}
+
+func (e *Match) Evaluate(d interface{}, opts ...any) (bool, error) {
+	if e.Value == nil || e.Pattern == nil {
+		return false, fmt.Errorf("missing operands")
+	}
+	vs, err := operandValues(d, opts, e.Value)
+	if err != nil {
+		return false, err
+	}
+	return e.Pattern.MatchString(vs[0].String()), nil
+}
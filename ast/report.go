@@ -0,0 +1,21 @@
+package ast
+
+// ReportingOperation is an Operation which can say what it did once it has run, such as how many duplicates distinct
+// dropped.
+type ReportingOperation interface {
+	Operation
+	Report(logf func(format string, args ...any))
+}
+
+// Report has op, and the operations it is made of, say what they did with logf. Call it once the output has been
+// written, streamed operations only know about the entries which were read.
+func Report(op Operation, logf func(format string, args ...any)) {
+	switch op := op.(type) {
+	case *CompoundStatement:
+		for _, s := range op.Statements {
+			Report(s, logf)
+		}
+	case ReportingOperation:
+		op.Report(logf)
+	}
+}
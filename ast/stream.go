@@ -0,0 +1,212 @@
+package ast
+
+import (
+	"io"
+	"pimtrace"
+	"pimtrace/dataformats/tabledata"
+
+	"github.com/arran4/go-evaluator"
+)
+
+// StreamOperation is an Operation which can also run an entry at a time.
+type StreamOperation interface {
+	Operation
+	ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error)
+}
+
+// ExecuteStream runs op over s. Operations which need every entry at once, such as sort and summary, read the rest of
+// s into memory first.
+func ExecuteStream(op Operation, s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	if sop, ok := op.(StreamOperation); ok {
+		return sop.ExecuteStream(s, ctx)
+	}
+	d, err := pimtrace.Collect(s)
+	if err != nil {
+		return nil, err
+	}
+	d, err = op.Execute(d, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pimtrace.DataStream(d), nil
+}
+
+func (o *CompoundStatement) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	for _, op := range o.Statements {
+		var err error
+		s, err = ExecuteStream(op, s, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+var _ StreamOperation = (*CompoundStatement)(nil)
+
+type filterStream struct {
+	pimtrace.Stream
+	expression *evaluator.Query
+	ctx        *evaluator.Context
+}
+
+func (s *filterStream) Next() (pimtrace.Entry, error) {
+	for {
+		e, err := s.Stream.Next()
+		if err != nil {
+			return nil, err
+		}
+		if keep, err := s.expression.Evaluate(evaluatorEntryWrapper{e}, s.ctx); err == nil && keep {
+			return e, nil
+		}
+	}
+}
+
+func (f FilterStatement) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &filterStream{Stream: s, expression: f.Expression, ctx: ctx}, nil
+}
+
+var _ StreamOperation = (*FilterStatement)(nil)
+
+type tableStream struct {
+	source  pimtrace.Stream
+	t       *TableTransformer
+	headers map[string]int
+	ctx     *evaluator.Context
+}
+
+func (s *tableStream) Next() (pimtrace.Entry, error) {
+	e, err := s.source.Next()
+	if err != nil {
+		return nil, err
+	}
+	return s.t.row(e, s.headers, s.ctx)
+}
+
+func (s *tableStream) NewSelf() pimtrace.Data {
+	return tabledata.Data{}
+}
+
+func (t *TableTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &tableStream{source: s, t: t, headers: t.headers(), ctx: ctx}, nil
+}
+
+var _ StreamOperation = (*TableTransformer)(nil)
+
+type limitStream struct {
+	pimtrace.Stream
+	remaining int
+}
+
+func (s *limitStream) Next() (pimtrace.Entry, error) {
+	if s.remaining <= 0 {
+		return nil, io.EOF
+	}
+	s.remaining--
+	return s.Stream.Next()
+}
+
+// ExecuteStream stops reading s once N entries have been read.
+func (l *LimitTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &limitStream{Stream: s, remaining: l.N}, nil
+}
+
+var _ StreamOperation = (*LimitTransformer)(nil)
+
+type offsetStream struct {
+	pimtrace.Stream
+	skip int
+}
+
+func (s *offsetStream) Next() (pimtrace.Entry, error) {
+	for ; s.skip > 0; s.skip-- {
+		if _, err := s.Stream.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return s.Stream.Next()
+}
+
+func (o *OffsetTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &offsetStream{Stream: s, skip: o.N}, nil
+}
+
+var _ StreamOperation = (*OffsetTransformer)(nil)
+
+// ExecuteStream reads all of s but only holds the best N entries in memory.
+func (t *TopTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	d, err := t.top(s, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pimtrace.DataStream(d), nil
+}
+
+var _ StreamOperation = (*TopTransformer)(nil)
+
+type distinctStream struct {
+	pimtrace.Stream
+	t    *DistinctTransformer
+	seen map[string]struct{}
+	ctx  *evaluator.Context
+}
+
+func (s *distinctStream) Next() (pimtrace.Entry, error) {
+	for {
+		e, err := s.Stream.Next()
+		if err != nil {
+			return nil, err
+		}
+		first, err := s.t.first(s.seen, e, s.ctx)
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			return e, nil
+		}
+	}
+}
+
+// ExecuteStream only holds the keys of the entries seen so far in memory.
+func (t *DistinctTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	t.Dropped = 0
+	return &distinctStream{Stream: s, t: t, seen: map[string]struct{}{}, ctx: ctx}, nil
+}
+
+var _ StreamOperation = (*DistinctTransformer)(nil)
+
+type rowStream struct {
+	source pimtrace.Stream
+	row    rowFunc
+	ctx    *evaluator.Context
+}
+
+func (s *rowStream) Next() (pimtrace.Entry, error) {
+	e, err := s.source.Next()
+	if err != nil {
+		return nil, err
+	}
+	return s.row(e, s.ctx)
+}
+
+func (s *rowStream) NewSelf() pimtrace.Data {
+	return tabledata.Data{}
+}
+
+func (t *ExtendTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &rowStream{source: s, row: t.row, ctx: ctx}, nil
+}
+
+var _ StreamOperation = (*ExtendTransformer)(nil)
+
+func (t *DropTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &rowStream{source: s, row: t.row, ctx: ctx}, nil
+}
+
+var _ StreamOperation = (*DropTransformer)(nil)
+
+func (t *RenameTransformer) ExecuteStream(s pimtrace.Stream, ctx *evaluator.Context) (pimtrace.Stream, error) {
+	return &rowStream{source: s, row: t.row, ctx: ctx}, nil
+}
+
+var _ StreamOperation = (*RenameTransformer)(nil)
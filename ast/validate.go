@@ -0,0 +1,379 @@
+package ast
+
+import (
+	"fmt"
+	"pimtrace/funcs"
+	"sort"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+)
+
+var (
+	ErrUnknownColumn = fmt.Errorf("unknown column")
+	ErrArgumentCount = fmt.Errorf("wrong number of arguments")
+)
+
+// ValidatingOperation is an Operation which can check its expressions against the columns of its input before it is
+// run. It returns the columns of its output, nil meaning any column may exist.
+type ValidatingOperation interface {
+	Operation
+	Validate(columns []string) ([]string, error)
+}
+
+// Validate checks the function names, argument counts and column references of op before it is run. columns are the
+// columns of the input, or nil when any column may exist such as with mail headers.
+func Validate(op Operation, columns []string) error {
+	_, err := validate(op, columns)
+	return err
+}
+
+func validate(op Operation, columns []string) ([]string, error) {
+	if vop, ok := op.(ValidatingOperation); ok {
+		return vop.Validate(columns)
+	}
+	return nil, nil
+}
+
+func (o *CompoundStatement) Validate(columns []string) ([]string, error) {
+	for _, op := range o.Statements {
+		var err error
+		if columns, err = validate(op, columns); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*CompoundStatement)(nil)
+
+func (f FilterStatement) Validate(columns []string) ([]string, error) {
+	if f.Expression == nil {
+		return columns, nil
+	}
+	if err := validateCondition(f.Expression.Expression, columns); err != nil {
+		return nil, fmt.Errorf("filter: %w", err)
+	}
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*FilterStatement)(nil)
+
+func (t *TableTransformer) Validate(columns []string) ([]string, error) {
+	result := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		if err := ValidateExpression(c.Operation, columns); err != nil {
+			return nil, fmt.Errorf("table: %w", err)
+		}
+		result = append(result, c.Name)
+	}
+	return result, nil
+}
+
+var _ ValidatingOperation = (*TableTransformer)(nil)
+
+func (s *SortTransformer) Validate(columns []string) ([]string, error) {
+	for _, e := range s.Expression {
+		if err := ValidateExpression(e, columns); err != nil {
+			return nil, fmt.Errorf("sort: %w", err)
+		}
+	}
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*SortTransformer)(nil)
+
+func (l *LimitTransformer) Validate(columns []string) ([]string, error) {
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*LimitTransformer)(nil)
+
+func (o *OffsetTransformer) Validate(columns []string) ([]string, error) {
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*OffsetTransformer)(nil)
+
+func (t *TopTransformer) Validate(columns []string) ([]string, error) {
+	for _, e := range t.Sort.Expression {
+		if err := ValidateExpression(e, columns); err != nil {
+			return nil, fmt.Errorf("top: %w", err)
+		}
+	}
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*TopTransformer)(nil)
+
+func (t *DistinctTransformer) Validate(columns []string) ([]string, error) {
+	for _, e := range t.Expression {
+		if err := ValidateExpression(e, columns); err != nil {
+			return nil, fmt.Errorf("distinct: %w", err)
+		}
+	}
+	return columns, nil
+}
+
+var _ ValidatingOperation = (*DistinctTransformer)(nil)
+
+func (t *ExtendTransformer) Validate(columns []string) ([]string, error) {
+	result := append([]string{}, columns...)
+	for _, c := range t.Columns {
+		if err := ValidateExpression(c.Operation, columns); err != nil {
+			return nil, fmt.Errorf("extend: %w", err)
+		}
+		if !containsString(result, c.Name) {
+			result = append(result, c.Name)
+		}
+	}
+	if columns == nil {
+		return nil, nil
+	}
+	return result, nil
+}
+
+var _ ValidatingOperation = (*ExtendTransformer)(nil)
+
+func (t *DropTransformer) Validate(columns []string) ([]string, error) {
+	if columns == nil {
+		return nil, nil
+	}
+	for _, c := range t.Columns {
+		if indexOf(columns, c) < 0 {
+			return nil, fmt.Errorf("drop: %w", validateEntry(EntryExpression("c."+c), columns))
+		}
+	}
+	result := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if indexOf(t.Columns, c) < 0 {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+var _ ValidatingOperation = (*DropTransformer)(nil)
+
+func (t *RenameTransformer) Validate(columns []string) ([]string, error) {
+	if columns == nil {
+		return nil, nil
+	}
+	result := append([]string{}, columns...)
+	for _, r := range t.Renames {
+		i := indexOf(result, r.From)
+		if i < 0 {
+			return nil, fmt.Errorf("rename: %w", validateEntry(EntryExpression("c."+r.From), result))
+		}
+		if j := indexOf(result, r.To); j >= 0 && j != i {
+			return nil, fmt.Errorf("rename %s: %w: %s", r.From, ErrColumnExists, r.To)
+		}
+		result[i] = r.To
+	}
+	return result, nil
+}
+
+var _ ValidatingOperation = (*RenameTransformer)(nil)
+
+// Validate returns the summary columns followed by the input columns, as a summary row looks up any other column in
+// the entries of its group.
+func (g *GroupTransformer) Validate(columns []string) ([]string, error) {
+	result := make([]string, 0, len(g.Columns)+len(columns))
+	for _, c := range g.Columns {
+		if err := ValidateExpression(c.Operation, columns); err != nil {
+			return nil, fmt.Errorf("summary: %w", err)
+		}
+		result = append(result, c.Name)
+	}
+	if columns == nil {
+		return nil, nil
+	}
+	return append(result, columns...), nil
+}
+
+var _ ValidatingOperation = (*GroupTransformer)(nil)
+
+// ValidateExpression checks the functions and column references of e, see Validate.
+func ValidateExpression(e ValueExpression, columns []string) error {
+	switch e := e.(type) {
+	case EntryExpression:
+		return validateEntry(e, columns)
+	case *FunctionExpression:
+		e.LoadFunction()
+		if e.F == nil {
+			return unknownFunction(e.Function)
+		}
+		if err := validateArguments(e.F, len(e.Args)); err != nil {
+			return err
+		}
+		for _, arg := range e.Args {
+			if err := ValidateExpression(arg, columns); err != nil {
+				return err
+			}
+		}
+	case *EvaluatorFunctionExpression:
+		f, ok := funcs.Functions[ValueExpression]()[e.Function]
+		if !ok {
+			return unknownFunction(e.Function)
+		}
+		if err := validateArguments(f, len(e.Args)); err != nil {
+			return err
+		}
+		for _, arg := range e.Args {
+			if arg, ok := arg.(ValueExpression); ok {
+				if err := ValidateExpression(arg, columns); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateEntry checks e names one of columns. Columns are matched exactly as table rows look them up, a column in
+// another case is only suggested.
+func validateEntry(e EntryExpression, columns []string) error {
+	if columns == nil {
+		return nil
+	}
+	prefix, name, _ := strings.Cut(string(e), ".")
+	switch prefix {
+	case "sz", "sized":
+		return nil
+	case "h", "header", "c", "column", "p", "property":
+		if containsString(columns, name) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s%s", ErrUnknownColumn, e, didYouMean(name, prefix+".", columns))
+	}
+	return fmt.Errorf("%w: %s%s", ErrUnknownColumn, e, didYouMean(name, "c.", columns))
+}
+
+func validateField(field string, columns []string) error {
+	if strings.Contains(field, ".") {
+		// Looked up by prefix like an entry
+		return validateEntry(EntryExpression(field), columns)
+	}
+	if columns == nil || containsString(columns, field) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s%s", ErrUnknownColumn, field, didYouMean(field, "", columns))
+}
+
+func validateCondition(e evaluator.Expression, columns []string) error {
+	switch e := e.(type) {
+	case *evaluator.NotExpression:
+		return validateCondition(e.Expression.Expression, columns)
+	case *evaluator.AndExpression:
+		for _, q := range e.Expressions {
+			if err := validateCondition(q.Expression, columns); err != nil {
+				return err
+			}
+		}
+	case *evaluator.OrExpression:
+		for _, q := range e.Expressions {
+			if err := validateCondition(q.Expression, columns); err != nil {
+				return err
+			}
+		}
+	case *evaluator.IsExpression:
+		return validateField(e.Field, columns)
+	case *evaluator.ContainsExpression:
+		return validateField(e.Field, columns)
+	case *evaluator.IContainsExpression:
+		return validateField(e.Field, columns)
+	case *Op:
+		if _, ok := Ops[e.Op]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownOperator, e.Op)
+		}
+		return validateExpressions(columns, e.LHS, e.RHS)
+	case *Between:
+		return validateExpressions(columns, e.Value, e.Low, e.High)
+	case *Match:
+		return validateExpressions(columns, e.Value)
+	case *In:
+		return validateExpressions(columns, e.Value)
+	}
+	return nil
+}
+
+func validateExpressions(columns []string, es ...ValueExpression) error {
+	for _, e := range es {
+		if e == nil {
+			continue
+		}
+		if err := ValidateExpression(e, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unknownFunction(name string) error {
+	functions := funcs.Functions[ValueExpression]()
+	names := make([]string, 0, len(functions))
+	for n := range functions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%w: f.%s%s", ErrUnknownFunction, name, didYouMean(name, "f.", names))
+}
+
+func validateArguments(f funcs.Function[ValueExpression], n int) error {
+	var signatures []string
+	for _, al := range f.Arguments() {
+		if al.Accepts(n) {
+			return nil
+		}
+		signatures = append(signatures, al.Signature(f.Name()))
+	}
+	return fmt.Errorf("%w: f.%s given %d, expected %s", ErrArgumentCount, f.Name(), n, strings.Join(signatures, " or "))
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// didYouMean suggests the closest of candidates to name, ignoring case, if any are close enough to be a typo.
+func didYouMean(name string, prefix string, candidates []string) string {
+	limit := len(name) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	best, bestDistance := "", limit+1
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(c)); d < bestDistance && d < len(name) {
+			best, bestDistance = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %s%s?", prefix, best)
+}
+
+// editDistance is the Levenshtein distance between a and b in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -19,10 +19,10 @@ func InputHandler(inputType string, inputFile string, ops ...any) (pimtrace.Data
 	}
 	var rows []*tabledata.Row
 	switch inputType {
-	case "csv":
+	case "csv", "tsv":
 		switch inputFile {
 		case "-":
-			nm, err := tabledata.ReadCSV(os.Stdin, inputType, inputFile)
+			nm, err := tabledata.ReadCSV(os.Stdin, inputType, inputFile, dataformats.NextOptions(ops)...)
 			if err != nil {
 				return nil, err
 			}
@@ -42,9 +42,31 @@ func InputHandler(inputType string, inputFile string, ops ...any) (pimtrace.Data
 	return tabledata.Data(rows), nil
 }
 
+// InputStreamHandler streams csv and tsv input a row at a time.
+func InputStreamHandler(inputType string, inputFile string, ops ...any) (pimtrace.Stream, error) {
+	switch inputType {
+	case "csv", "tsv":
+		next := func(f io.Reader) pimtrace.Stream {
+			return tabledata.NewCSVStream(f, inputType, inputFile, dataformats.NextOptions(ops)...)
+		}
+		switch inputFile {
+		case "-":
+			return dataformats.ReaderStream(os.Stdin, next, ops...)
+		default:
+			return dataformats.OpenStream(inputType, inputFile, next, ops...)
+		}
+	}
+	d, err := InputHandler(inputType, inputFile, ops...)
+	if err != nil {
+		return nil, err
+	}
+	return pimtrace.DataStream(d), nil
+}
+
 func PrintInputHelp(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "input-types available: ")
 	_, _ = fmt.Fprintf(w, " %-30s %s\n", "csv", "Read a CSV file")
+	_, _ = fmt.Fprintf(w, " %-30s %s\n", "tsv", "Read a tab separated file")
 	_, _ = fmt.Fprintf(w, " %-30s %s\n", "list", "This help text")
 	_, _ = fmt.Fprintln(w)
 }
@@ -6,9 +6,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"pimtrace"
 	"pimtrace/argparsers/basic"
 	"pimtrace/ast"
 	"pimtrace/dataformats"
+	"pimtrace/dataformats/tabledata"
+	"pimtrace/fsys"
 	"pimtrace/funcs"
 
 	"github.com/arran4/go-evaluator"
@@ -31,7 +34,27 @@ func main() {
 		parser      = f.String("parser", "", "Just use `basic`")
 		versionFlag = f.Bool("version", false, "Prints the version")
 		helpFlag    = f.Bool("help", false, "Prints help")
+		query       = f.String("query", "", "The query, or - to read a query script from stdin")
+		queryFile   = f.String("query-file", "", "Read the query from a script file, see the query files section of the help")
+		run         = f.String("run", "", "Run the saved query with this name, see -list-queries")
+		params      = basic.Params{}
+		listQueries = f.Bool("list-queries", false, "Lists the saved queries")
+		explain     = f.Bool("explain", false, "Prints how the query will run, reading only the header of the input")
+		verbose     = f.Bool("verbose", false, "Reports what the query did once it has run, such as how many duplicates distinct dropped")
+		queriesFile = f.String("queries", basic.DefaultSavedQueriesFile(), "The saved queries file")
+		arraySep    = f.String("array-separator", pimtrace.ArraySeparator, "Separator used when rendering multi-value cells")
+		schema      = f.String("schema", "", "Column types, eg: `Amount:float,Date:date,Paid:bool` (types: string, int, float, date, bool)")
+		infer       = f.Int("infer", 0, "Infer column types from the first N rows, 0 disables inference")
+		delimiter   = f.String("delimiter", "", "Input field delimiter, a character or one of: tab, comma, semicolon, pipe, space (default: comma, tab for tsv)")
+		comment     = f.String("comment", "", "Lines starting with this character are ignored, eg: #")
+		lazyQuotes  = f.Bool("lazy-quotes", false, "Allow quotes to appear in unquoted fields and non-doubled quotes in quoted fields")
+		trimSpace   = f.Bool("trim-leading-space", false, "Ignore leading white space in fields")
+		noHeader    = f.Bool("no-header", false, "The input has no header row, columns are named col1, col2, ...")
+		strict      = f.Bool("strict", false, "Fail on rows with the wrong number of fields or duplicate headers instead of repairing them")
+		outputDelim = f.String("output-delimiter", "", "Output field delimiter for csv output, same values as -delimiter")
+		outputNoHdr = f.Bool("output-no-header", false, "Don't write a header row for csv output")
 	)
+	f.Var(params, "param", "A `name=value` parameter for -run, can be repeated")
 	f.Usage = func() {
 		_, _ = fmt.Println("Usage: ", os.Args[0], "[Flags]", "[Query]")
 		f.PrintDefaults()
@@ -47,6 +70,18 @@ func main() {
 		log.Printf("Error parsing flags: %s", err)
 		os.Exit(-1)
 	}
+	pimtrace.ArraySeparator = *arraySep
+
+	fs := fsys.NewOSFS()
+	if *listQueries {
+		qs, err := basic.LoadSavedQueries(*queriesFile, fs)
+		if err != nil {
+			log.Printf("Query Error: %s", err)
+			os.Exit(-1)
+		}
+		basic.PrintSavedQueries(os.Stdout, qs)
+		return
+	}
 
 	if *helpFlag || len(os.Args) <= 1 {
 		_, _ = fmt.Println("No query found")
@@ -54,16 +89,16 @@ func main() {
 		os.Exit(-1)
 	}
 
-	data, err := InputHandler(*inputType, *inputFile)
+	args, err := basic.QueryArgs(f.Args(), *query, *queryFile, *inputFile, *run, params, *queriesFile, os.Stdin, fs)
 	if err != nil {
-		log.Printf("Read Error: %s", err)
+		log.Printf("Query Error: %s", err)
 		os.Exit(-1)
 	}
 
 	var ops ast.Operation
 	switch *parser {
 	case "basic":
-		ops, err = basic.ParseOperations(f.Args())
+		ops, err = basic.ParseOperations(args, fs)
 		if err != nil {
 			log.Printf("Parse Error: %s", err)
 			os.Exit(-1)
@@ -73,6 +108,68 @@ func main() {
 		os.Exit(-1)
 	}
 
+	iops := []any{fs}
+
+	if *schema != "" {
+		s, err := tabledata.ParseSchema(*schema)
+		if err != nil {
+			log.Printf("Schema Error: %s", err)
+			os.Exit(-1)
+		}
+		iops = append(iops, s)
+	}
+
+	if *infer > 0 {
+		iops = append(iops, tabledata.InferTypes(*infer))
+	}
+
+	dialect, err := inputDialect(*delimiter, *comment)
+	if err != nil {
+		log.Printf("Dialect Error: %s", err)
+		os.Exit(-1)
+	}
+	dialect.LazyQuotes = *lazyQuotes
+	dialect.TrimLeadingSpace = *trimSpace
+	dialect.NoHeader = *noHeader
+	iops = append(iops, dialect, tabledata.Strict(*strict))
+
+	if tabledata.OutputDialect.Comma, err = tabledata.ParseDelimiter(*outputDelim); err != nil {
+		log.Printf("Dialect Error: %s", err)
+		os.Exit(-1)
+	}
+	tabledata.OutputDialect.NoHeader = *outputNoHdr
+
+	data, err := InputStreamHandler(*inputType, *inputFile, iops...)
+	if err != nil {
+		log.Printf("Read Error: %s", err)
+		os.Exit(-1)
+	}
+	if c, ok := data.(io.Closer); ok {
+		defer func() {
+			if err := c.Close(); err != nil {
+				log.Printf("Read Error: %s", err)
+			}
+		}()
+	}
+
+	columns, err := pimtrace.Columns(data)
+	if err != nil {
+		log.Printf("Read Error: %s", err)
+		os.Exit(-1)
+	}
+	if err := ast.Validate(ops, columns); err != nil {
+		log.Printf("Parse Error: %s", err)
+		os.Exit(-1)
+	}
+
+	if *explain {
+		if err := ast.Explain(os.Stdout, ops, ast.Shape{Type: "table", Columns: columns}); err != nil {
+			log.Printf("Write Error: %s", err)
+			os.Exit(-1)
+		}
+		return
+	}
+
 	if ops != nil {
 		ctx := &evaluator.Context{
 			Functions: map[string]evaluator.Function{
@@ -81,16 +178,19 @@ func main() {
 				"as":    &funcs.AsAdapter{},
 			},
 		}
-		data, err = ops.Execute(data, ctx)
+		data, err = ast.ExecuteStream(ops, data, ctx)
 		if err != nil {
 			log.Printf("Execute Error: %s", err)
 			os.Exit(-1)
 		}
 	}
-	if err := dataformats.OutputHandler(data, *outputType, *outputFile, customOutputs); err != nil {
+	if err := dataformats.StreamOutputHandler(data, *outputType, *outputFile, customOutputs); err != nil {
 		log.Printf("Write Error: %s", err)
 		os.Exit(-1)
 	}
+	if *verbose {
+		ast.Report(ops, log.Printf)
+	}
 }
 
 func PrintQueryHelp(w io.Writer, parser string) {
@@ -116,3 +216,15 @@ func PrintQueryHelp(w io.Writer, parser string) {
 	dataformats.PrintOutputHelp(customOutputs)
 	_, _ = fmt.Fprintln(w, "")
 }
+
+func inputDialect(delimiter, comment string) (tabledata.Dialect, error) {
+	var d tabledata.Dialect
+	var err error
+	if d.Comma, err = tabledata.ParseDelimiter(delimiter); err != nil {
+		return d, err
+	}
+	if d.Comment, err = tabledata.ParseDelimiter(comment); err != nil {
+		return d, err
+	}
+	return d, nil
+}
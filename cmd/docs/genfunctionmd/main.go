@@ -7,7 +7,6 @@ import (
 	"pimtrace/ast"
 	"pimtrace/funcs"
 	"sort"
-	"strings"
 )
 
 func main() {
@@ -33,12 +32,7 @@ func main() {
 	for _, funName := range funNames {
 		fun := functions[funName]
 		for _, af := range fun.Arguments() {
-			args := make([]string, 0, len(af.Args))
-			for _, aff := range af.Args {
-				args = append(args, aff.String())
-			}
-			fn := fmt.Sprintf("f.%s[%s]", fun.Name(), strings.Join(args, ","))
-			_, _ = fmt.Fprintf(f, "| `%s` | %s |\n", fn, af.Description)
+			_, _ = fmt.Fprintf(f, "| `%s` | %s |\n", af.Signature(fun.Name()), af.Description)
 		}
 	}
 }
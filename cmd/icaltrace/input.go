@@ -42,6 +42,27 @@ func InputHandler(inputType string, inputFile string, ops ...any) (pimtrace.Data
 	return icaldata.Data(ventry), nil
 }
 
+// InputStreamHandler streams ical input a component at a time, other input types are read by InputHandler.
+func InputStreamHandler(inputType string, inputFile string, ops ...any) (pimtrace.Stream, error) {
+	switch inputType {
+	case "ical":
+		next := func(f io.Reader) pimtrace.Stream {
+			return icaldata.NewICalStream(f, inputType, inputFile)
+		}
+		switch inputFile {
+		case "-":
+			return dataformats.ReaderStream(os.Stdin, next, ops...)
+		default:
+			return dataformats.OpenStream(inputType, inputFile, next, ops...)
+		}
+	}
+	d, err := InputHandler(inputType, inputFile, ops...)
+	if err != nil {
+		return nil, err
+	}
+	return pimtrace.DataStream(d), nil
+}
+
 func PrintInputHelp(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "input-types available: ")
 	_, _ = fmt.Fprintf(w, " %-30s %s\n", "ical", "Read an iCal file or '-' for stdin")
@@ -157,3 +157,28 @@ func TestInputHandler_File(t *testing.T) {
 		t.Errorf("InputHandler(ical, file) expected empty data")
 	}
 }
+
+func TestInputStreamHandler_File(t *testing.T) {
+	mockFS := fsystest.MapFSAdapter{
+		MapFS: fstest.MapFS{
+			"test.ics": &fstest.MapFile{Data: []byte("BEGIN:VCALENDAR\nVERSION:2.0\nBEGIN:VEVENT\nUID:1\nEND:VEVENT\nBEGIN:VEVENT\nUID:2\nEND:VEVENT\nEND:VCALENDAR\n")},
+		},
+	}
+
+	s, err := InputStreamHandler("ical", "test.ics", mockFS)
+	if err != nil {
+		t.Fatalf("InputStreamHandler(ical, file) error: %v", err)
+	}
+	if c, ok := s.(io.Closer); !ok {
+		t.Errorf("InputStreamHandler(ical, file) should close the file")
+	} else {
+		defer func() { _ = c.Close() }()
+	}
+	d, err := pimtrace.Collect(s)
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if d.Len() != 2 {
+		t.Errorf("InputStreamHandler(ical, file) got %d entries, want 2", d.Len())
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"pimtrace"
 	"pimtrace/argparsers/basic"
 	"pimtrace/ast"
 	"pimtrace/dataformats"
+	"pimtrace/dataformats/tabledata"
+	"pimtrace/fsys"
 	"pimtrace/funcs"
 
 	"github.com/arran4/go-evaluator"
@@ -30,7 +33,21 @@ func main() {
 		parser      = f.String("parser", "", "Just use `basic`")
 		versionFlag = f.Bool("version", false, "Prints the version")
 		helpFlag    = f.Bool("help", false, "Prints help")
+		query       = f.String("query", "", "The query, or - to read a query script from stdin")
+		queryFile   = f.String("query-file", "", "Read the query from a script file, see the query files section of the help")
+		run         = f.String("run", "", "Run the saved query with this name, see -list-queries")
+		params      = basic.Params{}
+		listQueries = f.Bool("list-queries", false, "Lists the saved queries")
+		explain     = f.Bool("explain", false, "Prints how the query will run without reading the input")
+		verbose     = f.Bool("verbose", false, "Reports what the query did once it has run, such as how many duplicates distinct dropped")
+		queriesFile = f.String("queries", basic.DefaultSavedQueriesFile(), "The saved queries file")
+		arraySep    = f.String("array-separator", pimtrace.ArraySeparator, "Separator used when rendering multi-value cells")
+		outputDelim = f.String("output-delimiter", "", "Output field delimiter for csv output, a character or one of: tab, comma, semicolon, pipe, space")
+		outputNoHdr = f.Bool("output-no-header", false, "Don't write a header row for csv output")
+		allHeaders  = f.Bool("all-headers", false, "Write the headers of every entry for csv and table output, not just the first entry's")
+		sortHeaders = f.Bool("sort-headers", false, "Write headers in alphabetical order for csv and table output")
 	)
+	f.Var(params, "param", "A `name=value` parameter for -run, can be repeated")
 	f.Usage = func() {
 		_, _ = fmt.Println("Usage: ", os.Args[0], "[Flags]", "[Query]")
 		f.PrintDefaults()
@@ -46,6 +63,29 @@ func main() {
 		log.Printf("Error parsing flags: %s", err)
 		os.Exit(-1)
 	}
+	pimtrace.ArraySeparator = *arraySep
+	if comma, err := tabledata.ParseDelimiter(*outputDelim); err != nil {
+		log.Printf("Dialect Error: %s", err)
+		os.Exit(-1)
+	} else {
+		tabledata.OutputDialect.Comma = comma
+	}
+	tabledata.OutputDialect.NoHeader = *outputNoHdr
+	tabledata.OutputHeaders.Union = *allHeaders
+	if *sortHeaders {
+		tabledata.OutputHeaders.Order = tabledata.AlphabeticalOrder
+	}
+
+	fs := fsys.NewOSFS()
+	if *listQueries {
+		qs, err := basic.LoadSavedQueries(*queriesFile, fs)
+		if err != nil {
+			log.Printf("Query Error: %s", err)
+			os.Exit(-1)
+		}
+		basic.PrintSavedQueries(os.Stdout, qs)
+		return
+	}
 
 	if *helpFlag || len(os.Args) <= 1 {
 		_, _ = fmt.Println("No query found")
@@ -53,16 +93,16 @@ func main() {
 		os.Exit(-1)
 	}
 
-	data, err := InputHandler(*inputType, *inputFile)
+	args, err := basic.QueryArgs(f.Args(), *query, *queryFile, *inputFile, *run, params, *queriesFile, os.Stdin, fs)
 	if err != nil {
-		log.Printf("Read Error: %s", err)
+		log.Printf("Query Error: %s", err)
 		os.Exit(-1)
 	}
 
 	var ops ast.Operation
 	switch *parser {
 	case "basic":
-		ops, err = basic.ParseOperations(f.Args())
+		ops, err = basic.ParseOperations(args, fs)
 		if err != nil {
 			log.Printf("Parse Error: %s", err)
 			os.Exit(-1)
@@ -72,7 +112,38 @@ func main() {
 		os.Exit(-1)
 	}
 
+	if *explain {
+		// Properties vary from component to component so, as when the query is run, only the columns the query makes are checked
+		if err := ast.Validate(ops, nil); err != nil {
+			log.Printf("Parse Error: %s", err)
+			os.Exit(-1)
+		}
+		if err := ast.Explain(os.Stdout, ops, ast.Shape{Type: "ical"}); err != nil {
+			log.Printf("Write Error: %s", err)
+			os.Exit(-1)
+		}
+		return
+	}
+
+	data, err := InputStreamHandler(*inputType, *inputFile, fs)
+	if err != nil {
+		log.Printf("Read Error: %s", err)
+		os.Exit(-1)
+	}
+	if c, ok := data.(io.Closer); ok {
+		defer func() {
+			if err := c.Close(); err != nil {
+				log.Printf("Read Error: %s", err)
+			}
+		}()
+	}
+
 	if ops != nil {
+		// iCal properties vary by component so only functions are checked
+		if err := ast.Validate(ops, nil); err != nil {
+			log.Printf("Parse Error: %s", err)
+			os.Exit(-1)
+		}
 		ctx := &evaluator.Context{
 			Functions: map[string]evaluator.Function{
 				"year":  &funcs.YearAdapter{},
@@ -80,16 +151,19 @@ func main() {
 				"as":    &funcs.AsAdapter{},
 			},
 		}
-		data, err = ops.Execute(data, ctx)
+		data, err = ast.ExecuteStream(ops, data, ctx)
 		if err != nil {
 			log.Printf("Execute Error: %s", err)
 			os.Exit(-1)
 		}
 	}
-	if err := OutputHandler(data, *outputType, *outputFile); err != nil {
+	if err := OutputStreamHandler(data, *outputType, *outputFile); err != nil {
 		log.Printf("Write Error: %s", err)
 		os.Exit(-1)
 	}
+	if *verbose {
+		ast.Report(ops, log.Printf)
+	}
 }
 
 func PrintQueryHelp(w io.Writer, parser string) {
@@ -20,7 +20,7 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string) error {
 		if np, ok := p.(pimtrace.ICalFileOutputCapable); ok {
 			switch outputPath {
 			case "-":
-				return np.WriteICalStream(os.Stdin, outputPath)
+				return np.WriteICalStream(os.Stdout, outputPath)
 			default:
 				return np.WriteICalFile(outputPath)
 			}
@@ -30,3 +30,16 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string) error {
 	}
 	return dataformats.OutputHandler(p, mode, outputPath, customOutputs)
 }
+
+// OutputStreamHandler writes s using dataformats.StreamOutputHandler, the ical output reads the whole stream first.
+func OutputStreamHandler(s pimtrace.Stream, mode, outputPath string) error {
+	switch mode {
+	case "ical":
+		d, err := pimtrace.Collect(s)
+		if err != nil {
+			return err
+		}
+		return OutputHandler(d, mode, outputPath)
+	}
+	return dataformats.StreamOutputHandler(s, mode, outputPath, customOutputs)
+}
@@ -80,6 +80,30 @@ func InputHandler(inputType string, inputFile string, ops ...any) (pimtrace.Data
 	return maildata.Data(mails), nil
 }
 
+// InputStreamHandler streams mbox input a message at a time, other input types are read by InputHandler.
+func InputStreamHandler(inputType string, inputFile string, ops ...any) (pimtrace.Stream, error) {
+	switch inputType {
+	case "mboxgz":
+		ops = append(ops, dataformats.Gzip)
+		fallthrough
+	case "mbox":
+		next := func(f io.Reader) pimtrace.Stream {
+			return maildata.NewMBoxStream(f, inputType, inputFile)
+		}
+		switch inputFile {
+		case "-":
+			return dataformats.ReaderStream(os.Stdin, next, ops...)
+		default:
+			return dataformats.OpenStream(inputType, inputFile, next, ops...)
+		}
+	}
+	d, err := InputHandler(inputType, inputFile, ops...)
+	if err != nil {
+		return nil, err
+	}
+	return pimtrace.DataStream(d), nil
+}
+
 func PrintInputHelp(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "input-types available: ")
 	_, _ = fmt.Fprintf(w, " %-30s %s\n", "mailfile", "A single mail file")
@@ -21,7 +21,7 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string) error {
 		if np, ok := p.(pimtrace.MailFileOutputCapable); ok {
 			switch outputPath {
 			case "-":
-				return np.WriteMailStream(os.Stdin, outputPath)
+				return np.WriteMailStream(os.Stdout, outputPath)
 			default:
 				return np.WriteMailFile(outputPath)
 			}
@@ -32,7 +32,7 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string) error {
 		if np, ok := p.(pimtrace.MBoxOutputCapable); ok {
 			switch outputPath {
 			case "-":
-				return np.WriteMBoxStream(os.Stdin, outputPath)
+				return np.WriteMBoxStream(os.Stdout, outputPath)
 			default:
 				return np.WriteMBoxFile(outputPath)
 			}
@@ -42,3 +42,16 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string) error {
 	}
 	return dataformats.OutputHandler(p, mode, outputPath, customOutputs)
 }
+
+// OutputStreamHandler writes s using dataformats.StreamOutputHandler, the mail outputs read the whole stream first.
+func OutputStreamHandler(s pimtrace.Stream, mode, outputPath string) error {
+	switch mode {
+	case "mailfile", "mbox":
+		d, err := pimtrace.Collect(s)
+		if err != nil {
+			return err
+		}
+		return OutputHandler(d, mode, outputPath)
+	}
+	return dataformats.StreamOutputHandler(s, mode, outputPath, customOutputs)
+}
@@ -36,9 +36,21 @@ func (s *Row) HeadersStringArray() (result []string) {
 	return
 }
 
+// StringArray returns the cells for header in order, or the whole row if header is nil.
 func (s *Row) StringArray(header []string) (result []string) {
-	for _, v := range s.Row {
-		result = append(result, v.String())
+	if header == nil {
+		for _, v := range s.Row {
+			result = append(result, v.String())
+		}
+		return
+	}
+	for _, h := range header {
+		i, ok := s.Headers[h]
+		if !ok || i >= len(s.Row) || s.Row[i] == nil {
+			result = append(result, "")
+			continue
+		}
+		result = append(result, s.Row[i].String())
 	}
 	return
 }
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/arran4/golang-ical"
 	"pimtrace"
+	"sort"
 	"strings"
+	"time"
 )
 
 var (
@@ -13,12 +15,19 @@ var (
 	ErrHeaderError = errors.New("header error")
 )
 
+// TimeProperties are the properties which are parsed into pimtrace.SimpleTimeValue when a component is read
+var TimeProperties = map[ics.ComponentProperty]func(*ics.ComponentBase) (time.Time, error){
+	ics.ComponentPropertyDtStart: (*ics.ComponentBase).GetStartAt,
+	ics.ComponentPropertyDtEnd:   (*ics.ComponentBase).GetEndAt,
+}
+
 type ICalWithSource struct {
 	Component     ics.Component
 	ComponentBase *ics.ComponentBase
 	SourceType    string
 	SourceFile    string
 	Header        map[string]int
+	Times         map[string]time.Time
 }
 
 var _ pimtrace.Entry = (*ICalWithSource)(nil)
@@ -28,11 +37,15 @@ func (s *ICalWithSource) Self() *ICalWithSource {
 	return s
 }
 
+// HeadersStringArray returns the property names in the order they appear in the component.
 func (s *ICalWithSource) HeadersStringArray() (result []string) {
 	result = make([]string, 0, len(s.Header))
 	for h := range s.Header {
 		result = append(result, h)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return s.Header[result[i]] < s.Header[result[j]]
+	})
 	return
 }
 
@@ -40,6 +53,7 @@ func (s *ICalWithSource) StringArray(header []string) (result []string) {
 	for _, h := range header {
 		i, ok := s.Header[h]
 		if !ok {
+			result = append(result, "")
 			continue
 		}
 		result = append(result, s.ComponentBase.Properties[i].Value)
@@ -53,20 +67,43 @@ func (s *ICalWithSource) Get(key string) (pimtrace.Value, error) {
 	case "sz", "sized":
 		return pimtrace.SimpleIntegerValue(len(s.ComponentBase.Properties)), nil
 	case "p", "property":
-		ks = ks[1:]
-		fallthrough
+		if len(ks) > 1 {
+			return s.property(ks[1], key)
+		}
+		return nil, fmt.Errorf("iCal get %w, %s", ErrKeyNotFound, key)
 	default:
 		if len(ks) > 1 {
-			i, ok := s.Header[ks[0]]
-			if !ok {
-				return nil, fmt.Errorf("iCal get %w, %s", ErrHeaderError, key)
-			}
-			return pimtrace.SimpleStringValue(s.ComponentBase.Properties[i].Value), nil
+			return s.property(ks[0], key)
 		}
 		return nil, fmt.Errorf("iCal get %w, %s", ErrKeyNotFound, key)
 	}
 }
 
+func (s *ICalWithSource) property(name string, key string) (pimtrace.Value, error) {
+	i, ok := s.Header[name]
+	if !ok {
+		return nil, fmt.Errorf("iCal get %w, %s", ErrHeaderError, key)
+	}
+	if t, ok := s.Times[name]; ok {
+		return pimtrace.SimpleTimeValue(t), nil
+	}
+	return pimtrace.SimpleStringValue(s.ComponentBase.Properties[i].Value), nil
+}
+
+// ParseTimes parses the TimeProperties present in the component into Times
+func (s *ICalWithSource) ParseTimes() {
+	for p, get := range TimeProperties {
+		t, err := get(s.ComponentBase)
+		if err != nil {
+			continue
+		}
+		if s.Times == nil {
+			s.Times = map[string]time.Time{}
+		}
+		s.Times[string(p)] = t
+	}
+}
+
 type Data []*ICalWithSource
 
 func (icd Data) Truncate(n int) pimtrace.Data {
@@ -1,10 +1,13 @@
 package icaldata
 
 import (
+	"errors"
+	"io"
 	"pimtrace"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	ics "github.com/arran4/golang-ical"
 )
@@ -36,11 +39,12 @@ func TestICalWithSource_Self(t *testing.T) {
 
 func TestICalWithSource_HeadersStringArray(t *testing.T) {
 	r := &ICalWithSource{
-		Header: map[string]int{"SUMMARY": 0, "DTSTART": 1},
+		Header: map[string]int{"SUMMARY": 0, "DTSTART": 1, "UID": 2},
 	}
 	res := r.HeadersStringArray()
-	if len(res) != 2 {
-		t.Errorf("HeadersStringArray() returned wrong length")
+	expected := []string{"SUMMARY", "DTSTART", "UID"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("HeadersStringArray() = %v, want %v", res, expected)
 	}
 }
 
@@ -58,7 +62,7 @@ func TestICalWithSource_StringArray(t *testing.T) {
 	}
 
 	res := r.StringArray([]string{"SUMMARY", "NONEXISTENT", "DTSTART"})
-	expected := []string{"Meeting", "20231027T100000Z"}
+	expected := []string{"Meeting", "", "20231027T100000Z"}
 	if !reflect.DeepEqual(res, expected) {
 		t.Errorf("StringArray() = %v, want %v", res, expected)
 	}
@@ -207,3 +211,71 @@ END:VCALENDAR`
 	// golang-ical parses line by line and might just return an empty calendar or error
 	_, _ = ReadICalStream(rBad, "ical", "bad.ics") // Just hitting it for coverage
 }
+
+func TestICalStream(t *testing.T) {
+	icalData := `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Australia/Sydney
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:1
+END:VEVENT
+BEGIN:VTODO
+UID:2
+END:VTODO
+BOGUS:line
+`
+	s := NewICalStream(strings.NewReader(icalData), "ical", "test.ics")
+	for _, want := range []string{"1", "2"} {
+		e, err := s.Next()
+		if err != nil {
+			t.Fatalf("Next() error: %v", err)
+		}
+		if v, err := e.Get("p.UID"); err != nil || v.String() != want {
+			t.Errorf("Next() UID = %v, %v, want %s", v, err, want)
+		}
+	}
+	// Components are returned before the rest of the file is read
+	if _, err := s.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("Next() error = %v, want a malformed calendar error", err)
+	}
+}
+
+func TestReadICalStream_Times(t *testing.T) {
+	icalData := `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Example Corp.//Cal//EN
+BEGIN:VEVENT
+UID:12345
+DTSTART:20231027T100000Z
+DTEND:20231027T113000Z
+SUMMARY:Test Event
+END:VEVENT
+END:VCALENDAR`
+
+	sources, err := ReadICalStream(strings.NewReader(icalData), "ical", "test.ics")
+	if err != nil {
+		t.Fatalf("ReadICalStream error: %v", err)
+	}
+	for key, want := range map[string]time.Time{
+		"p.DTSTART": time.Date(2023, 10, 27, 10, 0, 0, 0, time.UTC),
+		"p.DTEND":   time.Date(2023, 10, 27, 11, 30, 0, 0, time.UTC),
+	} {
+		v, err := sources[0].Get(key)
+		if err != nil {
+			t.Errorf("Get(%s) error: %v", key, err)
+			continue
+		}
+		if tv, ok := v.(pimtrace.SimpleTimeValue); !ok || !time.Time(tv).Equal(want) {
+			t.Errorf("Get(%s) = %#v, want %s", key, v, want)
+		}
+	}
+	v, err := sources[0].Get("p.SUMMARY")
+	if err != nil {
+		t.Fatalf("Get(p.SUMMARY) error: %v", err)
+	}
+	if sv, ok := v.(pimtrace.SimpleStringValue); !ok || string(sv) != "Test Event" {
+		t.Errorf("Get(p.SUMMARY) = %v, want Test Event", v)
+	}
+}
@@ -1,40 +1,133 @@
 package icaldata
 
 import (
+	"errors"
 	"fmt"
 	"github.com/arran4/golang-ical"
 	"io"
+	"pimtrace"
 )
 
 func ReadICalStream(f io.Reader, fType string, fName string, ops ...any) ([]*ICalWithSource, error) {
-	var result []*ICalWithSource
-	cs, err := ics.ParseCalendar(f)
+	d, err := pimtrace.Collect(NewICalStream(f, fType, fName))
 	if err != nil {
-		return nil, fmt.Errorf("ical stream: %w", err)
+		return nil, err
 	}
-	for _, ic := range cs.Components {
-		var cb *ics.ComponentBase
-		switch c := ic.(type) {
-		case *ics.VEvent:
-			cb = &c.ComponentBase
-		case *ics.VTodo:
-			cb = &c.ComponentBase
-		case *ics.VBusy:
-			cb = &c.ComponentBase
-		case *ics.VJournal:
-			cb = &c.ComponentBase
-		}
-		header := make(map[string]int, len(cb.Properties))
-		for i, p := range cb.Properties {
-			header[p.IANAToken] = i
-		}
-		result = append(result, &ICalWithSource{
-			Component:     ic,
-			ComponentBase: cb,
-			Header:        header,
-			SourceFile:    fName,
-			SourceType:    fType,
-		})
+	return d.(Data), nil
+}
+
+// ICalStream reads the components of an iCal file one at a time. Components other than events, todos, free/busy and
+// journal entries, such as time zones, are skipped.
+type ICalStream struct {
+	cs    *ics.CalendarStream
+	fType string
+	fName string
+	state string
+	line  int
+}
+
+var _ pimtrace.Stream = (*ICalStream)(nil)
+
+func NewICalStream(f io.Reader, fType string, fName string, ops ...any) *ICalStream {
+	return &ICalStream{
+		cs:    ics.NewCalendarStream(f),
+		fType: fType,
+		fName: fName,
+		state: "begin",
+	}
+}
+
+func (s *ICalStream) NewSelf() pimtrace.Data {
+	return Data{}
+}
+
+func (s *ICalStream) Next() (pimtrace.Entry, error) {
+	for {
+		c, err := s.component()
+		if err != nil {
+			return nil, err
+		}
+		if e := s.entry(c); e != nil {
+			return e, nil
+		}
+	}
+}
+
+// component follows the same states as ics.ParseCalendar, returning each component as it is parsed.
+func (s *ICalStream) component() (ics.Component, error) {
+	for {
+		l, err := s.cs.ReadLine()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("ical stream: %w", err)
+		}
+		s.line++
+		if l == nil || len(*l) == 0 {
+			if err != nil {
+				return nil, io.EOF
+			}
+			continue
+		}
+		p, perr := ics.ParseProperty(*l)
+		if perr != nil {
+			return nil, fmt.Errorf("ical stream: parsing line %d: %w", s.line, perr)
+		}
+		if p == nil {
+			return nil, fmt.Errorf("ical stream: parsing calendar line %d", s.line)
+		}
+		switch {
+		case s.state == "begin" && p.IANAToken == "BEGIN" && p.Value == "VCALENDAR":
+			s.state = "properties"
+		case s.state == "begin":
+			return nil, errors.New("ical stream: malformed calendar; expected begin")
+		case s.state == "end":
+			return nil, errors.New("ical stream: malformed calendar; unexpected end")
+		case p.IANAToken == "END" && p.Value == "VCALENDAR":
+			s.state = "end"
+		case p.IANAToken == "END":
+			return nil, errors.New("ical stream: malformed calendar; expected end")
+		case p.IANAToken == "BEGIN":
+			s.state = "components"
+			c, cerr := ics.GeneralParseComponent(s.cs, p)
+			if cerr != nil {
+				return nil, fmt.Errorf("ical stream: %w", cerr)
+			}
+			if c != nil {
+				return c, nil
+			}
+		case s.state == "components":
+			return nil, errors.New("ical stream: malformed calendar; expected begin or end")
+		}
+		if err != nil {
+			return nil, io.EOF
+		}
+	}
+}
+
+func (s *ICalStream) entry(ic ics.Component) *ICalWithSource {
+	var cb *ics.ComponentBase
+	switch c := ic.(type) {
+	case *ics.VEvent:
+		cb = &c.ComponentBase
+	case *ics.VTodo:
+		cb = &c.ComponentBase
+	case *ics.VBusy:
+		cb = &c.ComponentBase
+	case *ics.VJournal:
+		cb = &c.ComponentBase
+	default:
+		return nil
+	}
+	header := make(map[string]int, len(cb.Properties))
+	for i, p := range cb.Properties {
+		header[p.IANAToken] = i
+	}
+	ical := &ICalWithSource{
+		Component:     ic,
+		ComponentBase: cb,
+		Header:        header,
+		SourceFile:    s.fName,
+		SourceType:    s.fType,
 	}
-	return result, nil
+	ical.ParseTimes()
+	return ical
 }
@@ -12,6 +12,21 @@ import (
 
 type ReaderStreamMapper func(io.Reader) (io.Reader, error)
 
+// NextOption is an option which is passed through to the Next function reading the stream.
+type NextOption interface {
+	NextOption()
+}
+
+// NextOptions returns the options which are for the Next function.
+func NextOptions(ops []any) (result []any) {
+	for _, op := range ops {
+		if o, ok := op.(NextOption); ok {
+			result = append(result, o)
+		}
+	}
+	return
+}
+
 var Gzip ReaderStreamMapper = fgzip
 
 func fgzip(reader io.Reader) (io.Reader, error) {
@@ -121,6 +136,8 @@ func ReaderStreamMapperOptionProcessor(f io.Reader, ops []any) (io.Reader, []io.
 			}
 		case fsys.FS:
 			// File systems are processed elsewhere, ignore them here
+		case NextOption:
+			// Passed on to the reader, ignore them here
 		default:
 			return nil, closers, fmt.Errorf("unknown option: %d", i)
 		}
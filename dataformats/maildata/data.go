@@ -60,11 +60,15 @@ func (m *MailBodyGeneral) FormName() string {
 
 var _ MailBody = (*MailBodyGeneral)(nil)
 
+// TimeHeaders are the headers which are parsed into pimtrace.SimpleTimeValue when a message is read
+var TimeHeaders = []string{"Date"}
+
 type MailWithSource struct {
 	MailHeader mail.Header
 	MailBodies []MailBody
 	SourceType string
 	SourceFile string
+	Times      map[string]time.Time
 }
 
 var _ pimtrace.Entry = (*MailWithSource)(nil)
@@ -74,10 +78,17 @@ func (s *MailWithSource) Self() *MailWithSource {
 	return s
 }
 
+// HeadersStringArray returns the header names in the order they appear in MailHeader, once each.
 func (s *MailWithSource) HeadersStringArray() (result []string) {
 	result = make([]string, 0, s.MailHeader.Len())
-	for h := range s.MailHeader.Map() {
-		result = append(result, h)
+	seen := map[string]struct{}{}
+	for fields := s.MailHeader.Fields(); fields.Next(); {
+		k := textproto.CanonicalMIMEHeaderKey(fields.Key())
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		result = append(result, k)
 	}
 	return
 }
@@ -99,6 +110,9 @@ func (s *MailWithSource) Get(key string) (pimtrace.Value, error) {
 		fallthrough
 	default:
 		if len(ks) > 0 {
+			if t, ok := s.Times[textproto.CanonicalMIMEHeaderKey(ks[0])]; ok {
+				return pimtrace.SimpleTimeValue(t), nil
+			}
 			return pimtrace.SimpleStringValue(s.MailHeader.Get(ks[0])), nil
 		}
 		return nil, fmt.Errorf("mail get %w, %s", ErrKeyNotFound, key)
@@ -119,7 +133,24 @@ func (s *MailWithSource) From() string {
 	return "nobody"
 }
 
+// ParseTimes parses the TimeHeaders present in the message into Times
+func (s *MailWithSource) ParseTimes() {
+	for _, h := range TimeHeaders {
+		t := pimtrace.ParseTime(s.MailHeader.Get(h))
+		if t == nil {
+			continue
+		}
+		if s.Times == nil {
+			s.Times = map[string]time.Time{}
+		}
+		s.Times[textproto.CanonicalMIMEHeaderKey(h)] = *t
+	}
+}
+
 func (s *MailWithSource) Time() time.Time {
+	if t, ok := s.Times["Date"]; ok {
+		return t
+	}
 	d, err := s.MailHeader.Date()
 	if err != nil {
 		log.Printf("Error parsing mail date: %v", err)
@@ -129,15 +129,19 @@ func TestData_Methods(t *testing.T) {
 }
 
 func TestMailWithSource_HeadersStringArray(t *testing.T) {
-	h := mail.Header{}
-	h.Set("A", "1")
-	h.Set("B", "2")
-	m := &MailWithSource{MailHeader: h}
+	res, err := ReadMailStream(strings.NewReader("Subject: x\nfrom: a@example.com\nReceived: 1\nTo: b@example.com\nReceived: 2\n\nbody\n"), "mail", "test.eml")
+	if err != nil {
+		t.Fatalf("ReadMailStream error = %v", err)
+	}
 
-	got := m.HeadersStringArray()
-	// Map iteration order is random, so check existence
-	if len(got) != 2 {
-		t.Errorf("HeadersStringArray len = %d, want 2", len(got))
+	got := res[0].HeadersStringArray()
+	// Headers keep the order they first appear in the message
+	expected := []string{"Subject", "From", "Received", "To"}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("HeadersStringArray mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"1", "2"}, res[0].MailHeader.Values("Received")); diff != "" {
+		t.Errorf("Received values mismatch (-want +got):\n%s", diff)
 	}
 }
 
@@ -335,6 +339,15 @@ This is a test email body.
 	if res[0].MailHeader.Get("Subject") != "Test Email" {
 		t.Errorf("ReadMailStream subject = %v, want Test Email", res[0].MailHeader.Get("Subject"))
 	}
+
+	v, err := res[0].Get("h.date")
+	if err != nil {
+		t.Fatalf("Get(h.date) error = %v", err)
+	}
+	want := time.Date(1969, 2, 13, 23, 32, 54, 0, time.FixedZone("", -(3*60+30)*60))
+	if tv, ok := v.(pimtrace.SimpleTimeValue); !ok || !time.Time(tv).Equal(want) {
+		t.Errorf("Get(h.date) = %#v, want time %s", v, want)
+	}
 }
 
 func TestReadMBoxStream(t *testing.T) {
@@ -1,15 +1,19 @@
 package maildata
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"github.com/emersion/go-mbox"
 	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-message/textproto"
 	"github.com/jhillyerd/enmime"
 	"io"
 	"log"
+	"pimtrace"
 	"pimtrace/dataformats"
+	"sort"
 )
 
 func ReadMBoxStream(f io.Reader, fType string, fName string, ops ...any) (res []*MailWithSource, err error) {
@@ -29,23 +33,57 @@ func ReadMBoxStream(f io.Reader, fType string, fName string, ops ...any) (res []
 	if err != nil {
 		return nil, err
 	}
-	mbr := mbox.NewReader(ff)
-	var ms []*MailWithSource
-	for {
-		mr, nextErr := mbr.NextMessage()
+	d, err := pimtrace.Collect(NewMBoxStream(ff, fType, fName))
+	if err != nil {
+		return nil, err
+	}
+	return d.(Data), nil
+}
+
+// MBoxStream reads the messages of an Mbox one at a time.
+type MBoxStream struct {
+	mbr     *mbox.Reader
+	fType   string
+	fName   string
+	n       int
+	pending []*MailWithSource
+}
+
+var _ pimtrace.Stream = (*MBoxStream)(nil)
+
+func NewMBoxStream(f io.Reader, fType string, fName string, ops ...any) *MBoxStream {
+	return &MBoxStream{
+		mbr:   mbox.NewReader(f),
+		fType: fType,
+		fName: fName,
+	}
+}
+
+func (s *MBoxStream) NewSelf() pimtrace.Data {
+	return Data{}
+}
+
+// Next skips over messages which can't be parsed, logging them.
+func (s *MBoxStream) Next() (pimtrace.Entry, error) {
+	for len(s.pending) == 0 {
+		mr, nextErr := s.mbr.NextMessage()
 		if nextErr != nil && !errors.Is(nextErr, io.EOF) {
-			return nil, fmt.Errorf("reading message %d from Mbox %s: %w", len(ms)+1, fName, nextErr)
+			return nil, fmt.Errorf("reading message %d from Mbox %s: %w", s.n+1, s.fName, nextErr)
 		}
 		if mr == nil {
-			return ms, nil
+			return nil, io.EOF
 		}
-		mrms, readErr := ReadMailStream(mr, fType, fName)
+		s.n++
+		mrms, readErr := ReadMailStream(mr, s.fType, s.fName)
 		if readErr != nil {
-			log.Printf("parsing message %d from Mbox %s: %v", len(ms)+1, fName, readErr)
+			log.Printf("parsing message %d from Mbox %s: %v", s.n, s.fName, readErr)
 			continue
 		}
-		ms = append(ms, mrms...)
+		s.pending = mrms
 	}
+	e := s.pending[0]
+	s.pending = s.pending[1:]
+	return e, nil
 }
 
 func ReadMailStream(f io.Reader, fType string, fName string, ops ...any) (res []*MailWithSource, err error) {
@@ -65,7 +103,11 @@ func ReadMailStream(f io.Reader, fType string, fName string, ops ...any) (res []
 	if err != nil {
 		return nil, err
 	}
-	msg, err := enmime.ReadEnvelope(ff)
+	raw, err := io.ReadAll(ff)
+	if err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+	msg, err := enmime.ReadEnvelope(bytes.NewReader(raw))
 	if err != nil {
 		return nil, fmt.Errorf("reading message: %w", err)
 	}
@@ -75,8 +117,9 @@ func ReadMailStream(f io.Reader, fType string, fName string, ops ...any) (res []
 	mws := &MailWithSource{
 		SourceFile: fName,
 		SourceType: fType,
-		MailHeader: mail.HeaderFromMap(msg.Root.Header),
+		MailHeader: orderedHeader(raw, msg.Root.Header),
 	}
+	mws.ParseTimes()
 	mws.MailBodies = []MailBody{
 		&MailBodyGeneral{
 			Body:    bytes.NewBufferString(msg.HTML),
@@ -90,3 +133,34 @@ func ReadMailStream(f io.Reader, fType string, fName string, ops ...any) (res []
 	return []*MailWithSource{mws}, nil
 
 }
+
+// orderedHeader returns the header values parsed by enmime in the order their names first appear in the raw message,
+// as enmime only keeps them in a map. Names which can't be found in the raw header go last in alphabetical order.
+func orderedHeader(raw []byte, m map[string][]string) mail.Header {
+	var order []string
+	seen := map[string]bool{}
+	rh, _ := textproto.ReadHeader(bufio.NewReader(bytes.NewReader(raw)))
+	for fields := rh.Fields(); fields.Next(); {
+		if k := fields.Key(); !seen[k] && len(m[k]) > 0 {
+			seen[k] = true
+			order = append(order, k)
+		}
+	}
+	var rest []string
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	order = append(order, rest...)
+	// Add puts each field before the ones already added so they are added from last to first
+	var h mail.Header
+	for i := len(order) - 1; i >= 0; i-- {
+		vs := m[order[i]]
+		for j := len(vs) - 1; j >= 0; j-- {
+			h.Add(order[i], vs[j])
+		}
+	}
+	return h
+}
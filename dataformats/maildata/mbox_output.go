@@ -15,3 +15,11 @@ func (m *MBoxOutput) Execute(d pimtrace.Data, ctx *evaluator.Context) (pimtrace.
 	}
 	return d, nil
 }
+
+// DescribeStep describes writing entries as an mbox for ast.Explain.
+func (m *MBoxOutput) DescribeStep(entries string) string {
+	if entries != "mail" {
+		return fmt.Sprintf("into mbox, which fails as %s entries aren't mail", entries)
+	}
+	return "into mbox, writing the mail entries as messages"
+}
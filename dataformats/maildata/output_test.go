@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/emersion/go-message/mail"
 	"os"
+	"pimtrace/ast"
 	"testing"
 )
 
@@ -200,3 +201,29 @@ func TestWriteMailStream_Multipart(t *testing.T) {
 
 	_ = data.WriteMailStream(tmpFile, "file.eml")
 }
+
+func TestMBoxOutput_DescribeStep(t *testing.T) {
+	for _, test := range []struct {
+		In   ast.Shape
+		Want string
+	}{
+		{
+			In:   ast.Shape{Type: "mail"},
+			Want: "1. into mbox, writing the mail entries as messages\nOutput: mail\n",
+		},
+		{
+			In:   ast.Shape{Type: "table"},
+			Want: "1. into mbox, which fails as table entries aren't mail\nOutput: table\n",
+		},
+	} {
+		t.Run(test.In.Type, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := ast.Explain(b, &MBoxOutput{}, test.In); err != nil {
+				t.Fatalf("Explain() error = %v", err)
+			}
+			if b.String() != test.Want {
+				t.Errorf("Explain() = %q, want %q", b.String(), test.Want)
+			}
+		})
+	}
+}
@@ -1,20 +1,28 @@
 package dataformats
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"pimtrace"
 	"pimtrace/dataformats/plotoutput"
+	"pimtrace/dataformats/tabledata"
 	"reflect"
 )
 
 func OutputHandler(p pimtrace.Data, mode, outputPath string, customOutputs [][2]string) error {
 	switch mode {
+	case "tsv":
+		if tabledata.OutputDialect.Comma == 0 || tabledata.OutputDialect.Comma == ',' {
+			tabledata.OutputDialect.Comma = '\t'
+		}
+		fallthrough
 	case "csv":
 		if np, ok := p.(pimtrace.CSVOutputCapable); ok {
 			switch outputPath {
 			case "-":
-				return np.WriteCSVStream(os.Stdin, outputPath)
+				return np.WriteCSVStream(os.Stdout, outputPath)
 			default:
 				return np.WriteCSVFile(outputPath)
 			}
@@ -25,7 +33,7 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string, customOutputs [][2]
 		if np, ok := p.(pimtrace.TableOutputCapable); ok {
 			switch outputPath {
 			case "-":
-				return np.WriteTableStream(os.Stdin, outputPath)
+				return np.WriteTableStream(os.Stdout, outputPath)
 			default:
 				return np.WriteTableFile(outputPath)
 			}
@@ -48,11 +56,53 @@ func OutputHandler(p pimtrace.Data, mode, outputPath string, customOutputs [][2]
 	}
 }
 
+// StreamOutputHandler is OutputHandler for a pimtrace.Stream. The csv, tsv and count outputs are written an entry at a
+// time, every other output reads the whole stream first.
+func StreamOutputHandler(s pimtrace.Stream, mode, outputPath string, customOutputs [][2]string) error {
+	switch mode {
+	case "count":
+		n := 0
+		for {
+			_, err := s.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		_, _ = fmt.Println(n)
+		return nil
+	case "tsv", "csv":
+		if tabledata.OutputHeaders.Union {
+			break
+		}
+		if mode == "tsv" && (tabledata.OutputDialect.Comma == 0 || tabledata.OutputDialect.Comma == ',') {
+			tabledata.OutputDialect.Comma = '\t'
+		}
+		switch outputPath {
+		case "-":
+			return tabledata.StreamCsv(s, os.Stdout)
+		default:
+			return pimtrace.WriteFileWrapper("CSV", outputPath, func(f io.Writer, fName string) error {
+				return tabledata.StreamCsv(s, f)
+			})
+		}
+	}
+	d, err := pimtrace.Collect(s)
+	if err != nil {
+		return err
+	}
+	return OutputHandler(d, mode, outputPath, customOutputs)
+}
+
 func PrintOutputHelp(custom [][2]string) {
 	_, _ = fmt.Println("--output-types: ")
 	each := [][2]string{
 		{"list", "This help text"},
 		{"csv", "Data in csv format"},
+		{"tsv", "Data in tab separated format"},
 		{"table", "Data in a ascii table"},
 		{"count", "Just a count of rows"},
 		{"plot.bar", "Writes a plot of the data out, the data must be tabular and columns must be in the form of: string, number*"},
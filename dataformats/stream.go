@@ -0,0 +1,75 @@
+package dataformats
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"pimtrace"
+	"pimtrace/fsys"
+)
+
+// ClosingStream closes the reader behind a Stream once it is done with.
+type ClosingStream struct {
+	pimtrace.Stream
+	closers []io.Closer
+}
+
+// Close closes everything in reverse order of opening and returns the first error.
+func (s *ClosingStream) Close() (err error) {
+	for i := range s.closers {
+		fc := s.closers[len(s.closers)-i-1]
+		if cerr := fc.Close(); cerr != nil {
+			if err == nil {
+				err = fmt.Errorf("closing stream: %w", cerr)
+			} else {
+				log.Printf("error closing stream: %s", cerr)
+			}
+		}
+	}
+	s.closers = nil
+	return
+}
+
+var _ io.Closer = (*ClosingStream)(nil)
+
+func (s *ClosingStream) Columns() ([]string, error) {
+	return pimtrace.Columns(s.Stream)
+}
+
+var _ pimtrace.ColumnStream = (*ClosingStream)(nil)
+
+// ReaderStream applies the ReaderStreamMapper options to f and hands the result to next.
+func ReaderStream(f io.Reader, next func(f io.Reader) pimtrace.Stream, ops ...any) (*ClosingStream, error) {
+	ff, closers, err := ReaderStreamMapperOptionProcessor(f, ops)
+	s := &ClosingStream{closers: closers}
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	s.Stream = next(ff)
+	return s, nil
+}
+
+// OpenStream is the streaming equivalent of ReadFile, the file stays open until the stream is closed.
+func OpenStream(fType string, fName string, next func(f io.Reader) pimtrace.Stream, ops ...any) (*ClosingStream, error) {
+	fs := fsys.NewOSFS()
+	for _, op := range ops {
+		if o, ok := op.(fsys.FS); ok {
+			fs = o
+		}
+	}
+	f, err := fs.OpenFile(fName, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s %s: %w", fType, fName, err)
+	}
+	s, err := ReaderStream(f, next, ops...)
+	if err != nil {
+		if cerr := f.Close(); cerr != nil {
+			log.Printf("Error closing file: %s: %s", fName, cerr)
+		}
+		return nil, err
+	}
+	s.closers = append([]io.Closer{f}, s.closers...)
+	return s, nil
+}
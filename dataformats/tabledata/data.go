@@ -8,7 +8,9 @@ import (
 )
 
 var (
-	ErrKeyNotFound = errors.New("key not found")
+	ErrKeyNotFound     = errors.New("key not found")
+	ErrDuplicateHeader = errors.New("duplicate header")
+	ErrNotTabular      = errors.New("entry can't be written as a row")
 )
 
 type Header interface {
@@ -38,6 +40,9 @@ func (s *Row) Get(key string) (pimtrace.Value, error) {
 	default:
 		n, ok := s.Headers[ks[0]]
 		if ok && len(ks) > 0 {
+			if n >= len(s.Row) {
+				return &pimtrace.SimpleNilValue{}, nil
+			}
 			return s.Row[n], nil
 		}
 		return nil, fmt.Errorf("table row %w: %s", ErrKeyNotFound, key)
@@ -52,9 +57,21 @@ func (s *Row) HeadersStringArray() (result []string) {
 	return
 }
 
+// StringArray returns the cells for header in order, or the whole row if header is nil.
 func (s *Row) StringArray(header []string) (result []string) {
-	for _, v := range s.Row {
-		result = append(result, v.String())
+	if header == nil {
+		for _, v := range s.Row {
+			result = append(result, v.String())
+		}
+		return
+	}
+	for _, h := range header {
+		i, ok := s.Headers[h]
+		if !ok || i >= len(s.Row) || s.Row[i] == nil {
+			result = append(result, "")
+			continue
+		}
+		result = append(result, s.Row[i].String())
 	}
 	return
 }
@@ -1,10 +1,17 @@
 package tabledata
 
 import (
+	"encoding/csv"
+	"errors"
+	"io"
 	"pimtrace"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestRowGetSize(t *testing.T) {
@@ -162,7 +169,7 @@ val3,val4`
 	if rows[0].Headers["col1"] != 0 || rows[0].Headers["col2"] != 1 {
 		t.Errorf("ReadCSV headers incorrect")
 	}
-	if len(rows[0].Row) != 2 || string(rows[0].Row[0].(pimtrace.SimpleStringValue)) != "val1" {
+	if len(rows[0].Row) != 3 || string(rows[0].Row[0].(pimtrace.SimpleStringValue)) != "val1" {
 		t.Errorf("ReadCSV data incorrect")
 	}
 
@@ -175,3 +182,252 @@ val1,val2"bad`
 		t.Errorf("ReadCSV expected error on bad CSV")
 	}
 }
+
+func TestCSVStream(t *testing.T) {
+	broken := errors.New("broken reader")
+	r := io.MultiReader(strings.NewReader("A,B\n1,2\n"), iotest.ErrReader(broken))
+	s := NewCSVStream(r, "csv", "test.csv")
+	e, err := s.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if v, _ := e.Get("c.B"); v.String() != "2" {
+		t.Errorf("Next() B = %v, want 2", v)
+	}
+	// The first row was returned before the rest of the input was read
+	if _, err := s.Next(); !errors.Is(err, broken) {
+		t.Errorf("Next() error = %v, want %v", err, broken)
+	}
+}
+
+func TestCSVStream_Columns(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		CSV  string
+		Ops  []any
+		Want []string
+	}{
+		{Name: "Overflow is always a column", CSV: "B,A\n1,2\n", Want: []string{"B", "A", "overflow"}},
+		{Name: "Overflow has a unique name", CSV: "B,A,overflow\n1,2,3\n", Want: []string{"B", "A", "overflow", "overflow_2"}},
+				{Name: "Strict has no overflow", CSV: "B,A\n1,2\n", Ops: []any{Strict(true)}, Want: []string{"B", "A"}},
+		{Name: "Empty file", CSV: ""},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			s := NewCSVStream(strings.NewReader(test.CSV), "csv", "test.csv", test.Ops...)
+			got, err := s.Columns()
+			if err != nil {
+				t.Fatalf("Columns() error = %v", err)
+			}
+			if diff := cmp.Diff(got, test.Want); diff != "" {
+				t.Errorf("Columns() %s", diff)
+			}
+			// Reading the header for Columns doesn't lose the first row
+			if e, err := s.Next(); test.CSV != "" && (err != nil || e.(*Row).Row[0].String() != "1") {
+				t.Errorf("Next() = %v, %v", e, err)
+			}
+		})
+	}
+}
+
+func TestReadCSV_Types(t *testing.T) {
+	csvData := `Name,Amount,Count,Date,Paid,Code
+a,150.50,10,2023-10-27,true,007
+b,45,,2023-11-01,false,010
+c,x,3,2023-12-01,true,100`
+	date := func(y int, m time.Month, d int) pimtrace.Value {
+		return pimtrace.SimpleTimeValue(time.Date(y, m, d, 0, 0, 0, 0, time.Local))
+	}
+	for _, test := range []struct {
+		Name    string
+		Ops     []any
+		Want    [][]pimtrace.Value
+		WantErr error
+	}{
+		{
+			Name: "Inferred from a sample",
+			Ops:  []any{InferTypes(2)},
+			Want: [][]pimtrace.Value{
+				{pimtrace.SimpleStringValue("a"), pimtrace.SimpleStringValue("150.50"), pimtrace.SimpleIntegerValue(10), date(2023, 10, 27), pimtrace.SimpleBoolValue(true), pimtrace.SimpleStringValue("007"), &pimtrace.SimpleNilValue{}},
+				{pimtrace.SimpleStringValue("b"), pimtrace.SimpleFloatValue(45), &pimtrace.SimpleNilValue{}, date(2023, 11, 1), pimtrace.SimpleBoolValue(false), pimtrace.SimpleStringValue("010"), &pimtrace.SimpleNilValue{}},
+				{pimtrace.SimpleStringValue("c"), pimtrace.SimpleStringValue("x"), pimtrace.SimpleIntegerValue(3), date(2023, 12, 1), pimtrace.SimpleBoolValue(true), pimtrace.SimpleStringValue("100"), &pimtrace.SimpleNilValue{}},
+			},
+		},
+		{
+			Name: "Schema overrides inference",
+			Ops:  []any{InferTypes(2), Schema{"Code": pimtrace.String, "Count": pimtrace.Float}},
+			Want: [][]pimtrace.Value{
+				{pimtrace.SimpleStringValue("a"), pimtrace.SimpleStringValue("150.50"), pimtrace.SimpleFloatValue(10), date(2023, 10, 27), pimtrace.SimpleBoolValue(true), pimtrace.SimpleStringValue("007"), &pimtrace.SimpleNilValue{}},
+				{pimtrace.SimpleStringValue("b"), pimtrace.SimpleFloatValue(45), &pimtrace.SimpleNilValue{}, date(2023, 11, 1), pimtrace.SimpleBoolValue(false), pimtrace.SimpleStringValue("010"), &pimtrace.SimpleNilValue{}},
+				{pimtrace.SimpleStringValue("c"), pimtrace.SimpleStringValue("x"), pimtrace.SimpleFloatValue(3), date(2023, 12, 1), pimtrace.SimpleBoolValue(true), pimtrace.SimpleStringValue("100"), &pimtrace.SimpleNilValue{}},
+			},
+		},
+		{
+			Name:    "Schema values must convert",
+			Ops:     []any{Schema{"Amount": pimtrace.Float}},
+			WantErr: ErrConversion,
+		},
+		{
+			Name:    "Schema columns must exist",
+			Ops:     []any{Schema{"Missing": pimtrace.Float}},
+			WantErr: ErrSchemaColumn,
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			rows, err := ReadCSV(strings.NewReader(csvData), "csv", "test.csv", test.Ops...)
+			if !errors.Is(err, test.WantErr) {
+				t.Fatalf("ReadCSV() error = %v, want %v", err, test.WantErr)
+			}
+			var got [][]pimtrace.Value
+			for _, r := range rows {
+				got = append(got, r.Row)
+			}
+			if diff := cmp.Diff(test.Want, got, cmp.Comparer(func(a, b pimtrace.SimpleTimeValue) bool {
+				return time.Time(a).Equal(time.Time(b))
+			})); diff != "" {
+				t.Errorf("ReadCSV() rows differ:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadCSV_Dialect(t *testing.T) {
+	for _, test := range []struct {
+		Name  string
+		FType string
+		Input string
+		Ops   []any
+		Want  []map[string]string
+	}{
+		{
+			Name:  "Semicolons, comments and spaces",
+			FType: "csv",
+			Input: "# exported\nDate; Amount\n2023-01-01; 1,50\n# total\n2023-01-02; 2,00\n",
+			Ops:   []any{Dialect{Comma: ';', Comment: '#', TrimLeadingSpace: true}},
+			Want:  []map[string]string{{"Date": "2023-01-01", "Amount": "1,50", "overflow": ""}, {"Date": "2023-01-02", "Amount": "2,00", "overflow": ""}},
+		},
+		{
+			Name:  "TSV by input type",
+			FType: "tsv",
+			Input: "a\tb\n1\t2\n",
+			Want:  []map[string]string{{"a": "1", "b": "2", "overflow": ""}},
+		},
+		{
+			Name:  "TSV keeps its delimiter when other options are set",
+			FType: "tsv",
+			Input: "1\t2\"x\n",
+			Ops:   []any{Dialect{LazyQuotes: true, NoHeader: true}},
+			Want:  []map[string]string{{"col1": "1", "col2": "2\"x", "overflow": ""}},
+		},
+		{
+			Name:  "Headerless",
+			FType: "csv",
+			Input: "x,y\nz,w\n",
+			Ops:   []any{Dialect{NoHeader: true}},
+			Want:  []map[string]string{{"col1": "x", "col2": "y", "overflow": ""}, {"col1": "z", "col2": "w", "overflow": ""}},
+		},
+		{
+			Name:  "Byte order mark",
+			FType: "csv",
+			Input: "\xEF\xBB\xBFName\nBob\n",
+			Want:  []map[string]string{{"Name": "Bob", "overflow": ""}},
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			rows, err := ReadCSV(strings.NewReader(test.Input), test.FType, "test", test.Ops...)
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+			var got []map[string]string
+			for _, r := range rows {
+				m := map[string]string{}
+				for h, i := range r.Headers {
+					m[h] = r.Row[i].String()
+				}
+				got = append(got, m)
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("ReadCSV() rows differ:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadCSV_Malformed(t *testing.T) {
+	for _, test := range []struct {
+		Name    string
+		Input   string
+		Ops     []any
+		Want    []map[string]string
+		WantErr error
+		ErrText string
+	}{
+		{
+			Name:  "Duplicate and empty headers",
+			Input: "Name,Name,,Name_2\na,b,c,d\n",
+			Want:  []map[string]string{{"Name": "a", "Name_2": "b", "col3": "c", "Name_2_2": "d", "overflow": ""}},
+		},
+		{
+			Name:  "Short rows are padded",
+			Input: "a,b,c\n1\n",
+			Want:  []map[string]string{{"a": "1", "b": "", "c": "", "overflow": ""}},
+		},
+		{
+			Name:  "Long rows overflow",
+			Input: "a,b\n1,2\n3,4,5,6\n",
+			Want:  []map[string]string{{"a": "1", "b": "2", "overflow": ""}, {"a": "3", "b": "4", "overflow": "5, 6"}},
+		},
+		{
+			Name:    "Strict rejects ragged rows",
+			Input:   "a,b\n1,2\n3\n",
+			Ops:     []any{Strict(true)},
+			WantErr: csv.ErrFieldCount,
+			ErrText: "line 3",
+		},
+		{
+			Name:    "Strict rejects duplicate headers",
+			Input:   "a,a\n1,2\n",
+			Ops:     []any{Strict(true)},
+			WantErr: ErrDuplicateHeader,
+			ErrText: "line 1",
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			rows, err := ReadCSV(strings.NewReader(test.Input), "csv", "test", test.Ops...)
+			if test.ErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), test.ErrText) || !errors.Is(err, test.WantErr) {
+					t.Fatalf("ReadCSV() error = %v, want %v containing %q", err, test.WantErr, test.ErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+			var got []map[string]string
+			for _, r := range rows {
+				m := map[string]string{}
+				for h := range r.Headers {
+					v, err := r.Get("c." + h)
+					if err != nil {
+						t.Fatalf("Get(%s) error = %v", h, err)
+					}
+					m[h] = v.String()
+				}
+				got = append(got, m)
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("ReadCSV() rows differ:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRowGetShortRow(t *testing.T) {
+	r := &Row{Headers: map[string]int{"a": 0, "b": 1}, Row: []pimtrace.Value{pimtrace.SimpleStringValue("x")}}
+	v, err := r.Get("c.b")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if v.Type() != pimtrace.Nil {
+		t.Errorf("Get() = %v, want Nil", v)
+	}
+}
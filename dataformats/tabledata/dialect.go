@@ -0,0 +1,96 @@
+package tabledata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidDelimiter = errors.New("invalid delimiter")
+)
+
+// Dialect describes the flavour of a CSV file.
+type Dialect struct {
+	Comma            rune
+	Comment          rune
+	LazyQuotes       bool
+	TrimLeadingSpace bool
+	NoHeader         bool
+}
+
+func (s Dialect) NextOption() {}
+
+var (
+	CSVDialect = Dialect{Comma: ','}
+	TSVDialect = Dialect{Comma: '\t'}
+)
+
+// OutputDialect is used by WriteCsv, only Comma and NoHeader apply to output. A zero Comma writes commas.
+var OutputDialect = CSVDialect
+
+// ParseDelimiter accepts a single character or one of the names `tab`, `\t`, `comma`, `semicolon`, `pipe` and `space`.
+// An empty string returns 0.
+func ParseDelimiter(s string) (rune, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "pipe":
+		return '|', nil
+	case "space":
+		return ' ', nil
+	}
+	if r, n := utf8.DecodeRuneInString(s); n == len(s) && r != utf8.RuneError {
+		return r, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidDelimiter, s)
+}
+
+func (s Dialect) reader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(stripBOM(r))
+	if s.Comma != 0 {
+		cr.Comma = s.Comma
+	}
+	cr.Comment = s.Comment
+	cr.LazyQuotes = s.LazyQuotes
+	cr.TrimLeadingSpace = s.TrimLeadingSpace
+	return cr
+}
+
+func (s Dialect) writer(w io.Writer) *csv.Writer {
+	cw := csv.NewWriter(w)
+	if s.Comma != 0 {
+		cw.Comma = s.Comma
+	}
+	return cw
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// stripBOM drops a UTF-8 byte order mark from the start of the stream so it doesn't end up in the first header.
+func stripBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(b, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
+// GeneratedHeader names the columns of a headerless file col1 to coln.
+func GeneratedHeader(n int) map[string]int {
+	header := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		header[fmt.Sprintf("col%d", i+1)] = i
+	}
+	return header
+}
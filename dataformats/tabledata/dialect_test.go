@@ -0,0 +1,32 @@
+package tabledata
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDelimiter(t *testing.T) {
+	for _, test := range []struct {
+		Input   string
+		Want    rune
+		WantErr error
+	}{
+		{Input: "", Want: 0},
+		{Input: ";", Want: ';'},
+		{Input: "tab", Want: '\t'},
+		{Input: `\t`, Want: '\t'},
+		{Input: "semicolon", Want: ';'},
+		{Input: "§", Want: '§'},
+		{Input: ";;", WantErr: ErrInvalidDelimiter},
+	} {
+		t.Run(test.Input, func(t *testing.T) {
+			got, err := ParseDelimiter(test.Input)
+			if !errors.Is(err, test.WantErr) {
+				t.Fatalf("ParseDelimiter() error = %v, want %v", err, test.WantErr)
+			}
+			if got != test.Want {
+				t.Errorf("ParseDelimiter() = %q, want %q", got, test.Want)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package tabledata
 
 import (
-	"encoding/csv"
+	"errors"
+	"fmt"
 	"github.com/olekukonko/tablewriter"
 	"io"
 	"pimtrace"
+	"sort"
 )
 
 var _ pimtrace.CSVOutputCapable = (*Data)(nil)
@@ -28,27 +30,96 @@ func (d Data) WriteTableStream(f io.Writer, fName string) error {
 	return nil
 }
 
-func WriteTable[T pimtrace.HasStringArray](d []T, f io.Writer) {
-	table := tablewriter.NewWriter(f)
-	var headers []string
+// HeaderOrder is the order of the columns written by WriteCsv and WriteTable.
+type HeaderOrder int
+
+const (
+	// FirstSeenOrder keeps the order of each entry's own headers, with new headers appended as they are found.
+	FirstSeenOrder HeaderOrder = iota
+	AlphabeticalOrder
+)
+
+type HeaderOptions struct {
+	// Union writes the headers of every entry rather than just the first one's.
+	Union bool
+	Order HeaderOrder
+}
+
+// OutputHeaders controls the columns written by WriteCsv and WriteTable.
+var OutputHeaders HeaderOptions
+
+// OutputHeaderList returns the columns to write for d according to OutputHeaders.
+func OutputHeaderList[T pimtrace.HasStringArray](d []T) (headers []string) {
+	seen := map[string]struct{}{}
 	for i, v := range d {
-		if i == 0 {
-			headers = v.HeadersStringArray()
-			table.SetHeader(headers)
+		if i > 0 && !OutputHeaders.Union {
+			break
+		}
+		for _, h := range v.HeadersStringArray() {
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+			headers = append(headers, h)
 		}
+	}
+	if OutputHeaders.Order == AlphabeticalOrder {
+		sort.Strings(headers)
+	}
+	return
+}
+
+func WriteTable[T pimtrace.HasStringArray](d []T, f io.Writer) {
+	table := tablewriter.NewWriter(f)
+	headers := OutputHeaderList(d)
+	if len(d) > 0 {
+		table.SetHeader(headers)
+	}
+	for _, v := range d {
 		table.Append(v.StringArray(headers))
 	}
 	table.Render() // Send output
 }
 
 func WriteCsv[T pimtrace.HasStringArray](d []T, f io.Writer) error {
-	table := csv.NewWriter(f)
+	table := OutputDialect.writer(f)
+	headers := OutputHeaderList(d)
+	if len(d) > 0 && !OutputDialect.NoHeader {
+		if err := table.Write(headers); err != nil {
+			return err
+		}
+	}
+	for _, v := range d {
+		if err := table.Write(v.StringArray(headers)); err != nil {
+			return err
+		}
+	}
+	table.Flush()
+	return table.Error()
+}
+
+// StreamCsv writes the entries of s as they are read, the columns are the first entry's headers.
+func StreamCsv(s pimtrace.Stream, f io.Writer) error {
+	table := OutputDialect.writer(f)
 	var headers []string
-	for i, v := range d {
+	for i := 0; ; i++ {
+		e, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		v, ok := e.(pimtrace.HasStringArray)
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrNotTabular, e)
+		}
 		if i == 0 {
-			headers = v.HeadersStringArray()
-			if err := table.Write(headers); err != nil {
-				return err
+			headers = OutputHeaderList([]pimtrace.HasStringArray{v})
+			if !OutputDialect.NoHeader {
+				if err := table.Write(headers); err != nil {
+					return err
+				}
 			}
 		}
 		if err := table.Write(v.StringArray(headers)); err != nil {
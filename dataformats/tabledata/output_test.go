@@ -3,6 +3,7 @@ package tabledata
 import (
 	"os"
 	"pimtrace"
+	"strings"
 	"testing"
 )
 
@@ -109,3 +110,104 @@ func TestWriteTableStream_WithData(t *testing.T) {
 		t.Errorf("WriteTableStream returned error: %v", err)
 	}
 }
+
+func TestWriteCsv_OutputDialect(t *testing.T) {
+	defer func(d Dialect) { OutputDialect = d }(OutputDialect)
+	headers := map[string]int{"A": 0, "B": 1}
+	data := Data{
+		&Row{Headers: headers, Row: []pimtrace.Value{pimtrace.SimpleStringValue("1"), pimtrace.SimpleStringValue("x;y")}},
+	}
+	for _, test := range []struct {
+		Name    string
+		Dialect Dialect
+		Want    string
+	}{
+		{Name: "Default", Dialect: Dialect{}, Want: "A,B\n1,x;y\n"},
+		{Name: "Semicolon", Dialect: Dialect{Comma: ';'}, Want: "A;B\n1;\"x;y\"\n"},
+		{Name: "Tab without header", Dialect: Dialect{Comma: '\t', NoHeader: true}, Want: "1\tx;y\n"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			OutputDialect = test.Dialect
+			b := &strings.Builder{}
+			if err := WriteCsv(data, b); err != nil {
+				t.Fatalf("WriteCsv() error = %v", err)
+			}
+			if got := b.String(); got != test.Want {
+				t.Errorf("WriteCsv() = %q, want %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestWriteCsv_OutputHeaders(t *testing.T) {
+	defer func(h HeaderOptions) { OutputHeaders = h }(OutputHeaders)
+	data := Data{
+		&Row{Headers: map[string]int{"B": 0, "A": 1}, Row: []pimtrace.Value{pimtrace.SimpleStringValue("b1"), pimtrace.SimpleStringValue("a1")}},
+		&Row{Headers: map[string]int{"C": 0, "A": 1}, Row: []pimtrace.Value{pimtrace.SimpleStringValue("c2"), pimtrace.SimpleStringValue("a2")}},
+	}
+	for _, test := range []struct {
+		Name    string
+		Options HeaderOptions
+		Want    string
+	}{
+		{Name: "First entry", Options: HeaderOptions{}, Want: "B,A\nb1,a1\n,a2\n"},
+		{Name: "Union", Options: HeaderOptions{Union: true}, Want: "B,A,C\nb1,a1,\n,a2,c2\n"},
+		{Name: "Union alphabetical", Options: HeaderOptions{Union: true, Order: AlphabeticalOrder}, Want: "A,B,C\na1,b1,\na2,,c2\n"},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			OutputHeaders = test.Options
+			b := &strings.Builder{}
+			if err := WriteCsv(data, b); err != nil {
+				t.Fatalf("WriteCsv() error = %v", err)
+			}
+			if got := b.String(); got != test.Want {
+				t.Errorf("WriteCsv() = %q, want %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestStreamCsv(t *testing.T) {
+	headers := map[string]int{"A": 0, "B": 1}
+	data := Data{
+		&Row{Headers: headers, Row: []pimtrace.Value{pimtrace.SimpleStringValue("1"), pimtrace.SimpleStringValue("2")}},
+		&Row{Headers: headers, Row: []pimtrace.Value{pimtrace.SimpleStringValue("3")}},
+	}
+	b := &strings.Builder{}
+	if err := StreamCsv(pimtrace.DataStream(data), b); err != nil {
+		t.Fatalf("StreamCsv() error = %v", err)
+	}
+	if got, want := b.String(), "A,B\n1,2\n3,\n"; got != want {
+		t.Errorf("StreamCsv() = %q, want %q", got, want)
+	}
+}
+
+func TestStreamCsv_LateOverflow(t *testing.T) {
+	// The long row is after both the first row and the type inference sample
+	s := NewCSVStream(strings.NewReader("a,b\n1,2\n3,4,5,6\n"), "csv", "test.csv", InferTypes(1))
+	b := &strings.Builder{}
+	if err := StreamCsv(s, b); err != nil {
+		t.Fatalf("StreamCsv() error = %v", err)
+	}
+	if got, want := b.String(), "a,b,overflow\n1,2,\n3,4,\"5, 6\"\n"; got != want {
+		t.Errorf("StreamCsv() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCsv_InferredRoundTrip(t *testing.T) {
+	csvData := "Zip,Price,Count\n02134,1.50,7\n90210,2,10\n"
+	rows, err := ReadCSV(strings.NewReader(csvData), "csv", "test.csv", InferTypes(10), Strict(true))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	b := &strings.Builder{}
+	if err := WriteCsv(rows, b); err != nil {
+		t.Fatalf("WriteCsv() error = %v", err)
+	}
+	if got := b.String(); got != csvData {
+		t.Errorf("WriteCsv() = %q, want %q", got, csvData)
+	}
+	if got := rows[1].Row[1]; got != pimtrace.SimpleFloatValue(2) {
+		t.Errorf("Price = %#v, want a float", got)
+	}
+}
@@ -0,0 +1,141 @@
+package tabledata
+
+import (
+	"errors"
+	"fmt"
+	"pimtrace"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownColumnType = errors.New("unknown column type")
+	ErrInvalidSchema     = errors.New("invalid schema")
+	ErrSchemaColumn      = errors.New("schema column not in header")
+	ErrConversion        = errors.New("can't convert value")
+)
+
+// Schema declares the type of columns by name, columns which aren't listed are left as strings unless inferred.
+type Schema map[string]pimtrace.Type
+
+func (s Schema) NextOption() {}
+
+// InferTypes enables guessing column types from the first n rows of a CSV file.
+type InferTypes int
+
+func (s InferTypes) NextOption() {}
+
+// ParseColumnType parses the name of a column type as used by ParseSchema.
+func ParseColumnType(s string) (pimtrace.Type, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "string", "text":
+		return pimtrace.String, nil
+	case "int", "integer":
+		return pimtrace.Integer, nil
+	case "float", "number", "decimal":
+		return pimtrace.Float, nil
+	case "date", "time", "datetime":
+		return pimtrace.Time, nil
+	case "bool", "boolean":
+		return pimtrace.Bool, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownColumnType, s)
+}
+
+// ParseSchema parses a list of column types such as `Amount:float,Date:date,Paid:bool`.
+func ParseSchema(s string) (Schema, error) {
+	result := Schema{}
+	for _, e := range strings.Split(s, ",") {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		i := strings.LastIndex(e, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("%w: expected column:type got %q", ErrInvalidSchema, e)
+		}
+		t, err := ParseColumnType(e[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+		}
+		result[strings.TrimSpace(e[:i])] = t
+	}
+	return result, nil
+}
+
+// ConvertValue converts a cell to type t, empty cells become Nil.
+func ConvertValue(s string, t pimtrace.Type) (pimtrace.Value, error) {
+	if s == "" && t != pimtrace.String {
+		return &pimtrace.SimpleNilValue{}, nil
+	}
+	switch t {
+	case pimtrace.String:
+		return pimtrace.SimpleStringValue(s), nil
+	case pimtrace.Integer:
+		if i, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			return pimtrace.SimpleIntegerValue(i), nil
+		}
+	case pimtrace.Float:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return pimtrace.SimpleFloatValue(f), nil
+		}
+	case pimtrace.Time:
+		if tm := pimtrace.ParseTime(s); tm != nil {
+			return pimtrace.SimpleTimeValue(*tm), nil
+		}
+	case pimtrace.Bool:
+		if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+			return pimtrace.SimpleBoolValue(b), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownColumnType, t)
+	}
+	return nil, fmt.Errorf("%w: %q to %s", ErrConversion, s, t)
+}
+
+// InferType returns the narrowest type every non-empty value converts to, trying Bool, Integer, Float then Time.
+// Numbers with leading zeros such as zip codes and IDs aren't numeric.
+func InferType(values []string) pimtrace.Type {
+	seen := false
+	candidates := []pimtrace.Type{pimtrace.Bool, pimtrace.Integer, pimtrace.Float, pimtrace.Time}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		seen = true
+		remaining := candidates[:0]
+		for _, t := range candidates {
+			if t == pimtrace.Bool && !isBoolWord(v) {
+				continue
+			}
+			if (t == pimtrace.Integer || t == pimtrace.Float) && hasLeadingZero(v) {
+				continue
+			}
+			if _, err := ConvertValue(v, t); err == nil {
+				remaining = append(remaining, t)
+			}
+		}
+		candidates = remaining
+		if len(candidates) == 0 {
+			return pimtrace.String
+		}
+	}
+	if !seen {
+		return pimtrace.String
+	}
+	return candidates[0]
+}
+
+// isBoolWord stops columns of 0s and 1s being inferred as Bool.
+func isBoolWord(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "false":
+		return true
+	}
+	return false
+}
+
+// hasLeadingZero reports if s has a zero before other digits such as "02134", which a number wouldn't keep.
+func hasLeadingZero(s string) bool {
+	s = strings.TrimLeft(strings.TrimSpace(s), "+-")
+	return len(s) > 1 && s[0] == '0' && s[1] >= '0' && s[1] <= '9'
+}
@@ -0,0 +1,58 @@
+package tabledata
+
+import (
+	"errors"
+	"pimtrace"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSchema(t *testing.T) {
+	for _, test := range []struct {
+		Name    string
+		Input   string
+		Want    Schema
+		WantErr error
+	}{
+		{Name: "Empty", Input: "", Want: Schema{}},
+		{Name: "Types", Input: "Amount:float,Date:date, Paid:Bool,Count:int,Code:string", Want: Schema{"Amount": pimtrace.Float, "Date": pimtrace.Time, "Paid": pimtrace.Bool, "Count": pimtrace.Integer, "Code": pimtrace.String}},
+		{Name: "Column with a colon", Input: "a:b:int", Want: Schema{"a:b": pimtrace.Integer}},
+		{Name: "Missing type", Input: "Amount", WantErr: ErrInvalidSchema},
+		{Name: "Unknown type", Input: "Amount:money", WantErr: ErrUnknownColumnType},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := ParseSchema(test.Input)
+			if !errors.Is(err, test.WantErr) {
+				t.Fatalf("ParseSchema() error = %v, want %v", err, test.WantErr)
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("ParseSchema() differs:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInferType(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Values []string
+		Want   pimtrace.Type
+	}{
+		{Name: "Integers", Values: []string{"1", "", "-20"}, Want: pimtrace.Integer},
+		{Name: "Integers and floats", Values: []string{"1", "2.5"}, Want: pimtrace.Float},
+		{Name: "Ones and zeros aren't bools", Values: []string{"1", "0"}, Want: pimtrace.Integer},
+		{Name: "Bools", Values: []string{"true", "FALSE"}, Want: pimtrace.Bool},
+		{Name: "Dates", Values: []string{"2023-10-27", "Fri, 27 Oct 2023 10:00:00 +1100"}, Want: pimtrace.Time},
+		{Name: "Mixed", Values: []string{"1", "abc"}, Want: pimtrace.String},
+		{Name: "Leading zeros aren't numbers", Values: []string{"02134", "90210"}, Want: pimtrace.String},
+		{Name: "Zero is a number", Values: []string{"0", "-0.5", "0.25"}, Want: pimtrace.Float},
+		{Name: "All empty", Values: []string{"", ""}, Want: pimtrace.String},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := InferType(test.Values); got != test.Want {
+				t.Errorf("InferType() = %s, want %s", got, test.Want)
+			}
+		})
+	}
+}
@@ -16,34 +16,288 @@ func RowsToData(r ...[]*Row) (result []Data) {
 }
 
 func ReadCSV(r io.Reader, fType string, fName string, ops ...any) ([]*Row, error) {
-	header := map[string]int{}
-	var result []*Row
-	cr := csv.NewReader(r)
-	for l := 0; ; l++ {
-		r, err := cr.Read()
+	d, err := pimtrace.Collect(NewCSVStream(r, fType, fName, ops...))
+	if err != nil {
+		return nil, err
+	}
+	rows := []*Row(d.(Data))
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows, nil
+}
+
+// CSVStream reads the rows of a CSV file one at a time. Only the rows sampled for type inference are held in memory.
+type CSVStream struct {
+	cr       *csv.Reader
+	fName    string
+	schema   Schema
+	infer    InferTypes
+	strict   Strict
+	dialect  Dialect
+	started  bool
+	startErr error
+	header   map[string]int
+	width    int
+	types    map[int]columnType
+	overflow int
+	buffer   [][]string
+	lines    []int
+}
+
+var _ pimtrace.Stream = (*CSVStream)(nil)
+
+// NewCSVStream accepts the same options as ReadCSV.
+func NewCSVStream(r io.Reader, fType string, fName string, ops ...any) *CSVStream {
+	s := &CSVStream{
+		fName:    fName,
+		dialect:  CSVDialect,
+		overflow: -1,
+	}
+	if fType == "tsv" {
+		s.dialect = TSVDialect
+	}
+	for _, op := range ops {
+		switch op := op.(type) {
+		case Schema:
+			s.schema = op
+		case InferTypes:
+			s.infer = op
+		case Strict:
+			s.strict = op
+		case Dialect:
+			if op.Comma == 0 {
+				op.Comma = s.dialect.Comma
+			}
+			s.dialect = op
+		}
+	}
+	s.cr = s.dialect.reader(r)
+	if !s.strict {
+		s.cr.FieldsPerRecord = -1
+	}
+	return s
+}
+
+func (s *CSVStream) NewSelf() pimtrace.Data {
+	return Data{}
+}
+
+func (s *CSVStream) Next() (pimtrace.Entry, error) {
+	if err := s.begin(); err != nil {
+		return nil, err
+	}
+	var r []string
+	var line int
+	if len(s.buffer) > 0 {
+		r, line = s.buffer[0], s.lines[0]
+		s.buffer, s.lines = s.buffer[1:], s.lines[1:]
+	} else {
+		var err error
+		r, line, err = s.read()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.row(r, line)
+}
+
+func (s *CSVStream) read() ([]string, int, error) {
+	r, err := s.cr.Read()
+	if err == io.EOF {
+		return nil, 0, io.EOF
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("csv %s: %w", s.fName, err)
+	}
+	line, _ := s.cr.FieldPos(0)
+	return r, line, nil
+}
+
+// Columns returns the column names in order, reading the header if it hasn't been already. Rows longer than the
+// header put their extra fields in OverflowColumn so it is included unless Strict, as a long row may come after the
+// first row is written out. Columns is nil for an empty file.
+func (s *CSVStream) Columns() ([]string, error) {
+	if err := s.begin(); err != nil {
+		return nil, err
+	}
+	if len(s.header) == 0 {
+		return nil, nil
+	}
+	columns := make([]string, len(s.header))
+	for h, i := range s.header {
+		columns[i] = h
+	}
+	return columns, nil
+}
+
+var _ pimtrace.ColumnStream = (*CSVStream)(nil)
+
+func (s *CSVStream) begin() error {
+	if !s.started {
+		s.started = true
+		s.startErr = s.start()
+	}
+	return s.startErr
+}
+
+// start reads the header and the rows sampled for type inference.
+func (s *CSVStream) start() error {
+	r, line, err := s.read()
+	if err == io.EOF {
+		s.header = map[string]int{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.width = len(r)
+	if s.dialect.NoHeader {
+		s.header = GeneratedHeader(s.width)
+		s.buffer, s.lines = [][]string{r}, []int{line}
+	} else if s.header, err = uniqueHeader(r, bool(s.strict)); err != nil {
+		return fmt.Errorf("csv %s line %d: %w", s.fName, line, err)
+	}
+	if !s.strict {
+		// Every row has the column, writers take their columns from the first row
+		s.overflow = len(s.header)
+		s.header[uniqueName(s.header, OverflowColumn)] = s.overflow
+	}
+	for len(s.buffer) < int(s.infer) {
+		r, line, err := s.read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("csv read: %w", err)
+			return err
 		}
-		if r == nil {
+		s.buffer, s.lines = append(s.buffer, r), append(s.lines, line)
+	}
+	if s.types, err = columnTypes(s.header, s.buffer, s.schema, s.infer); err != nil {
+		return fmt.Errorf("csv %s: %w", s.fName, err)
+	}
+	return nil
+}
+
+func (s *CSVStream) row(r []string, line int) (*Row, error) {
+	rv := make([]pimtrace.Value, len(s.header))
+	for i := range rv {
+		rv[i] = &pimtrace.SimpleNilValue{}
+	}
+	for i, e := range r {
+		if i >= s.width {
+			extra := make(pimtrace.SimpleArrayValue, 0, len(r)-s.width)
+			for _, e := range r[s.width:] {
+				extra = append(extra, pimtrace.SimpleStringValue(e))
+			}
+			rv[s.overflow] = extra
 			break
 		}
-		if l == 0 {
-			for i, c := range r {
-				header[c] = i
-			}
+		t, declared := s.types[i]
+		if !declared {
+			rv[i] = pimtrace.SimpleStringValue(e)
 			continue
 		}
-		rv := make([]pimtrace.Value, len(r))
-		for i, e := range r {
-			rv[i] = pimtrace.SimpleStringValue(e)
+		v, err := ConvertValue(e, t.Type)
+		if err != nil {
+			if t.Declared {
+				return nil, fmt.Errorf("csv %s line %d column %d: %w", s.fName, line, i+1, err)
+			}
+			v = pimtrace.SimpleStringValue(e)
+		}
+		if !t.Declared && !roundTrips(v, e) {
+			// Text such as "1.50" or "007" is kept as written, it still collates and sums as a number
+			v = pimtrace.SimpleStringValue(e)
+		}
+		rv[i] = v
+	}
+	return &Row{
+		Headers: s.header,
+		Row:     rv,
+	}, nil
+}
+
+// roundTrips reports if an inferred number v is written out as e was read.
+func roundTrips(v pimtrace.Value, e string) bool {
+	switch v.Type() {
+	case pimtrace.Integer, pimtrace.Float:
+		return v.String() == e
+	}
+	return true
+}
+
+// OverflowColumn holds the extra fields of rows which are longer than the header.
+const OverflowColumn = "overflow"
+
+// Strict makes ReadCSV fail on rows with a different number of fields to the header and on duplicate header names
+// rather than repairing them.
+type Strict bool
+
+func (s Strict) NextOption() {}
+
+// uniqueHeader maps header names to positions, renaming duplicates to Name_2, Name_3, ... and empty names to colN.
+func uniqueHeader(r []string, strict bool) (map[string]int, error) {
+	header := make(map[string]int, len(r))
+	for i, c := range r {
+		if c == "" {
+			c = fmt.Sprintf("col%d", i+1)
+		}
+		if strict && hasKey(header, c) {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateHeader, c)
+		}
+		header[uniqueName(header, c)] = i
+	}
+	return header, nil
+}
+
+func uniqueName(header map[string]int, name string) string {
+	if !hasKey(header, name) {
+		return name
+	}
+	for n := 2; ; n++ {
+		if c := fmt.Sprintf("%s_%d", name, n); !hasKey(header, c) {
+			return c
+		}
+	}
+}
+
+func hasKey(header map[string]int, key string) bool {
+	_, ok := header[key]
+	return ok
+}
+
+type columnType struct {
+	Type     pimtrace.Type
+	Declared bool
+}
+
+// columnTypes returns the type of each column position which isn't a plain string. Declared types come from the
+// schema and are enforced, inferred types fall back to strings for values after the sample which don't fit.
+func columnTypes(header map[string]int, records [][]string, schema Schema, infer InferTypes) (map[int]columnType, error) {
+	result := map[int]columnType{}
+	if infer > 0 {
+		sample := records
+		if len(sample) > int(infer) {
+			sample = sample[:infer]
+		}
+		for _, i := range header {
+			values := make([]string, 0, len(sample))
+			for _, r := range sample {
+				if i < len(r) {
+					values = append(values, r[i])
+				}
+			}
+			if t := InferType(values); t != pimtrace.String {
+				result[i] = columnType{Type: t}
+			}
+		}
+	}
+	for name, t := range schema {
+		i, ok := header[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSchemaColumn, name)
 		}
-		result = append(result, &Row{
-			Headers: header,
-			Row:     rv,
-		})
+		result[i] = columnType{Type: t, Declared: true}
 	}
 	return result, nil
 }
@@ -1,7 +1,9 @@
 package funcs
 
 import (
+	"fmt"
 	"pimtrace"
+	"strings"
 
 	"github.com/arran4/go-evaluator"
 )
@@ -28,6 +30,10 @@ func (a Argument) String() string {
 		return "Array"
 	case Any:
 		return "Any"
+	case Time:
+		return "Time"
+	case Bool:
+		return "Bool"
 	}
 	return "unknown"
 }
@@ -37,13 +43,37 @@ const (
 	Integer
 	Array
 	Any
+	Time
+	Bool
 )
 
 type ArgumentList struct {
-	Args        []Argument
+	Args []Argument
+	// Variadic means the last argument can be repeated
+	Variadic    bool
 	Description string
 }
 
+// Signature is how the argument list is written in a query, eg: f.year[String]
+func (al ArgumentList) Signature(name string) string {
+	args := make([]string, 0, len(al.Args))
+	for _, a := range al.Args {
+		args = append(args, a.String())
+	}
+	if al.Variadic && len(args) > 0 {
+		args[len(args)-1] += "..."
+	}
+	return fmt.Sprintf("f.%s[%s]", name, strings.Join(args, ","))
+}
+
+// Accepts reports whether n arguments fit the argument list.
+func (al ArgumentList) Accepts(n int) bool {
+	if al.Variadic {
+		return n >= len(al.Args)
+	}
+	return n == len(al.Args)
+}
+
 type Function[T ValueExpression] interface {
 	Name() string
 	Arguments() []ArgumentList
@@ -59,6 +89,12 @@ func Functions[T ValueExpression]() map[string]Function[T] {
 		Month[T]{},
 		Year[T]{},
 		As[T]{},
+		Contains[T]{},
+		IContains[T]{},
+		Eq[T]{},
+		And[T]{},
+		Or[T]{},
+		Not[T]{},
 	} {
 		m[f.Name()] = f
 	}
@@ -0,0 +1,83 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"pimtrace"
+	"strings"
+
+	"github.com/arran4/go-evaluator"
+)
+
+var (
+	ErrExpecting2ArgumentsAnyAny = errors.New("expecting 2 arguments: the value => any, the value to look for => any")
+)
+
+type Contains[T ValueExpression] struct{}
+
+var _ Function[ValueExpression] = Contains[ValueExpression]{}
+
+func (c Contains[T]) Name() string {
+	return "contains"
+}
+
+func (c Contains[T]) Arguments() []ArgumentList {
+	return []ArgumentList{
+		{
+			Args:        []Argument{Any, Any},
+			Description: "Returns true if the first value contains the second",
+		},
+	}
+}
+
+func (c Contains[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, error) {
+	lhs, rhs, err := Arg2(d, args, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("contains: %w", err)
+	}
+	return pimtrace.SimpleBoolValue(strings.Contains(lhs.String(), rhs.String())), nil
+}
+
+type IContains[T ValueExpression] struct{}
+
+var _ Function[ValueExpression] = IContains[ValueExpression]{}
+
+func (c IContains[T]) Name() string {
+	return "icontains"
+}
+
+func (c IContains[T]) Arguments() []ArgumentList {
+	return []ArgumentList{
+		{
+			Args:        []Argument{Any, Any},
+			Description: "Returns true if the first value contains the second ignoring case",
+		},
+	}
+}
+
+func (c IContains[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, error) {
+	lhs, rhs, err := Arg2(d, args, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("icontains: %w", err)
+	}
+	return pimtrace.SimpleBoolValue(strings.Contains(strings.ToLower(lhs.String()), strings.ToLower(rhs.String()))), nil
+}
+
+// Arg2 executes exactly two arguments, missing values are returned as Nil.
+func Arg2[T ValueExpression](d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, pimtrace.Value, error) {
+	if len(args) != 2 {
+		return nil, nil, ErrExpecting2ArgumentsAnyAny
+	}
+	var result [2]pimtrace.Value
+	for i, arg := range args {
+		v, err := arg.Execute(d, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if v == nil {
+			v = &pimtrace.SimpleNilValue{}
+		}
+		result[i] = v
+	}
+	return result[0], result[1], nil
+}
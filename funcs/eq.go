@@ -0,0 +1,33 @@
+package funcs
+
+import (
+	"fmt"
+	"pimtrace"
+
+	"github.com/arran4/go-evaluator"
+)
+
+type Eq[T ValueExpression] struct{}
+
+var _ Function[ValueExpression] = Eq[ValueExpression]{}
+
+func (c Eq[T]) Name() string {
+	return "eq"
+}
+
+func (c Eq[T]) Arguments() []ArgumentList {
+	return []ArgumentList{
+		{
+			Args:        []Argument{Any, Any},
+			Description: "Returns true if both values are equal",
+		},
+	}
+}
+
+func (c Eq[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, error) {
+	lhs, rhs, err := Arg2(d, args, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("eq: %w", err)
+	}
+	return pimtrace.SimpleBoolValue(pimtrace.Compare(lhs, rhs) == 0), nil
+}
@@ -0,0 +1,110 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"pimtrace"
+
+	"github.com/arran4/go-evaluator"
+)
+
+var (
+	ErrExpecting1ArgumentBool        = errors.New("expecting 1 argument: the value => bool")
+	ErrExpectingAtLeast1ArgumentBool = errors.New("expecting at least 1 argument: the values => bool")
+)
+
+type And[T ValueExpression] struct{}
+
+var _ Function[ValueExpression] = And[ValueExpression]{}
+
+func (c And[T]) Name() string {
+	return "and"
+}
+
+func (c And[T]) Arguments() []ArgumentList {
+	return []ArgumentList{
+		{
+			Args:        []Argument{Bool},
+			Variadic:    true,
+			Description: "Returns true if every argument is truthy",
+		},
+	}
+}
+
+func (c And[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("and: %w", ErrExpectingAtLeast1ArgumentBool)
+	}
+	for _, arg := range args {
+		v, err := arg.Execute(d, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("and: %w", err)
+		}
+		if v == nil || !v.Truthy() {
+			return pimtrace.SimpleBoolValue(false), nil
+		}
+	}
+	return pimtrace.SimpleBoolValue(true), nil
+}
+
+type Or[T ValueExpression] struct{}
+
+var _ Function[ValueExpression] = Or[ValueExpression]{}
+
+func (c Or[T]) Name() string {
+	return "or"
+}
+
+func (c Or[T]) Arguments() []ArgumentList {
+	return []ArgumentList{
+		{
+			Args:        []Argument{Bool},
+			Variadic:    true,
+			Description: "Returns true if any argument is truthy",
+		},
+	}
+}
+
+func (c Or[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("or: %w", ErrExpectingAtLeast1ArgumentBool)
+	}
+	for _, arg := range args {
+		v, err := arg.Execute(d, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("or: %w", err)
+		}
+		if v != nil && v.Truthy() {
+			return pimtrace.SimpleBoolValue(true), nil
+		}
+	}
+	return pimtrace.SimpleBoolValue(false), nil
+}
+
+type Not[T ValueExpression] struct{}
+
+var _ Function[ValueExpression] = Not[ValueExpression]{}
+
+func (c Not[T]) Name() string {
+	return "not"
+}
+
+func (c Not[T]) Arguments() []ArgumentList {
+	return []ArgumentList{
+		{
+			Args:        []Argument{Bool},
+			Description: "Returns true if the argument isn't truthy",
+		},
+	}
+}
+
+func (c Not[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrace.Value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("not: %w", ErrExpecting1ArgumentBool)
+	}
+	v, err := args[0].Execute(d, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("not: %w", err)
+	}
+	return pimtrace.SimpleBoolValue(v == nil || !v.Truthy()), nil
+}
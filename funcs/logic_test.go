@@ -0,0 +1,55 @@
+package funcs
+
+import (
+	"errors"
+	"pimtrace"
+	"pimtrace/dataformats/tabledata"
+	"testing"
+
+	"github.com/arran4/go-evaluator"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBooleanFunctions_Run(t *testing.T) {
+	row := &tabledata.Row{
+		Headers: map[string]int{"Subject": 0, "Amount": 1, "Empty": 2},
+		Row:     []pimtrace.Value{pimtrace.SimpleStringValue("URGENT: pay invoice"), pimtrace.SimpleStringValue("20.0"), &pimtrace.SimpleNilValue{}},
+	}
+	for _, test := range []struct {
+		Name      string
+		Function  Function[ValueExpression]
+		InputArgs []ValueExpression
+		Output    pimtrace.Value
+		Err       error
+	}{
+		{Name: "contains", Function: Contains[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Subject"), constantExpression("invoice")}, Output: pimtrace.SimpleBoolValue(true)},
+		{Name: "contains is case sensitive", Function: Contains[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Subject"), constantExpression("urgent")}, Output: pimtrace.SimpleBoolValue(false)},
+		{Name: "icontains", Function: IContains[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Subject"), constantExpression("urgent")}, Output: pimtrace.SimpleBoolValue(true)},
+		{Name: "contains wrong arguments", Function: Contains[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Subject")}, Err: ErrExpecting2ArgumentsAnyAny},
+		{Name: "eq is numeric", Function: Eq[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Amount"), constantExpression("20")}, Output: pimtrace.SimpleBoolValue(true)},
+		{Name: "eq nil", Function: Eq[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Empty"), constantExpression("")}, Output: pimtrace.SimpleBoolValue(false)},
+		{Name: "and", Function: And[ValueExpression]{}, InputArgs: []ValueExpression{constantExpression("x"), EntryExpression("c.Empty")}, Output: pimtrace.SimpleBoolValue(false)},
+		{Name: "and all true", Function: And[ValueExpression]{}, InputArgs: []ValueExpression{constantExpression("x"), EntryExpression("c.Amount")}, Output: pimtrace.SimpleBoolValue(true)},
+		{Name: "and no arguments", Function: And[ValueExpression]{}, Err: ErrExpectingAtLeast1ArgumentBool},
+		{Name: "or", Function: Or[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Empty"), constantExpression("x")}, Output: pimtrace.SimpleBoolValue(true)},
+		{Name: "or all false", Function: Or[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Empty"), constantExpression("")}, Output: pimtrace.SimpleBoolValue(false)},
+		{Name: "not", Function: Not[ValueExpression]{}, InputArgs: []ValueExpression{EntryExpression("c.Empty")}, Output: pimtrace.SimpleBoolValue(true)},
+		{Name: "not wrong arguments", Function: Not[ValueExpression]{}, Err: ErrExpecting1ArgumentBool},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			res, err := test.Function.Run(row, test.InputArgs, nil)
+			if !errors.Is(err, test.Err) {
+				t.Fatalf("Got error %v expected: %v", err, test.Err)
+			}
+			if diff := cmp.Diff(test.Output, res); diff != "" {
+				t.Errorf("Outputs differ: %s", diff)
+			}
+		})
+	}
+}
+
+type constantExpression string
+
+func (ve constantExpression) Execute(d pimtrace.Entry, ctx *evaluator.Context) (pimtrace.Value, error) {
+	return pimtrace.SimpleStringValue(ve), nil
+}
@@ -33,6 +33,10 @@ func (c Month[T]) Arguments() []ArgumentList {
 			Args:        []Argument{Integer},
 			Description: "Converts Unix time to a date and returns the month number of that date",
 		},
+		{
+			Args:        []Argument{Time},
+			Description: "Returns the month number of the date",
+		},
 	}
 }
 
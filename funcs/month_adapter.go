@@ -48,6 +48,8 @@ func (m *MonthAdapter) Call(args ...interface{}) (interface{}, error) {
 		if err != nil {
 			return nil, fmt.Errorf("parse error: %w", err)
 		}
+	case pimtrace.SimpleTimeValue:
+		t = time.Time(v)
 	case pimtrace.Value:
 		if i := v.Integer(); i != nil {
 			t = time.Unix(int64(*i), 0)
@@ -36,16 +36,24 @@ func (c Sum[T]) Run(d pimtrace.Entry, args []T, ctx *evaluator.Context) (pimtrac
 		return pimtrace.SimpleIntegerValue(1), nil
 	}
 	value := 0
+	fvalue := 0.0
+	isFloat := false
 	for i := 0; i < dd.Contents.Len(); i++ {
 		e := dd.Contents.Entry(i)
 		r, err := args[0].Execute(e, ctx)
 		if err != nil {
 			return nil, err
 		}
-		i := r.Integer()
-		if i != nil {
-			value += *i
+		switch n := pimtrace.NumericValue(r).(type) {
+		case pimtrace.SimpleIntegerValue:
+			value += int(n)
+		case pimtrace.SimpleFloatValue:
+			isFloat = true
+			fvalue += float64(n)
 		}
 	}
+	if isFloat {
+		return pimtrace.SimpleFloatValue(float64(value) + fvalue), nil
+	}
 	return pimtrace.SimpleIntegerValue(value), nil
 }
@@ -27,6 +27,19 @@ func TestSum_Run(t *testing.T) {
 		}
 	}
 
+	createValueGroupRow := func(values ...pimtrace.Value) *groupdata.Row {
+		rows := make([]*tabledata.Row, len(values))
+		for i, v := range values {
+			rows[i] = &tabledata.Row{
+				Headers: map[string]int{"val": 0},
+				Row:     []pimtrace.Value{v},
+			}
+		}
+		return &groupdata.Row{
+			Contents: tabledata.Data(rows),
+		}
+	}
+
 	for _, test := range []struct {
 		Name      string
 		Input     pimtrace.Entry
@@ -65,6 +78,27 @@ func TestSum_Run(t *testing.T) {
 			Output:    pimtrace.SimpleIntegerValue(0),
 			Err:       nil,
 		},
+		{
+			Name:      "Sum Float Strings keeps decimals",
+			Input:     createValueGroupRow(pimtrace.SimpleStringValue("150.50"), pimtrace.SimpleStringValue("45.25")),
+			InputArgs: []ValueExpression{EntryExpression("c.val")},
+			Output:    pimtrace.SimpleFloatValue(195.75),
+			Err:       nil,
+		},
+		{
+			Name:      "Sum Integer and Float promotes to Float",
+			Input:     createValueGroupRow(pimtrace.SimpleIntegerValue(10), pimtrace.SimpleFloatValue(0.5), pimtrace.SimpleStringValue("2")),
+			InputArgs: []ValueExpression{EntryExpression("c.val")},
+			Output:    pimtrace.SimpleFloatValue(12.5),
+			Err:       nil,
+		},
+		{
+			Name:      "Sum skips non numeric values",
+			Input:     createValueGroupRow(pimtrace.SimpleStringValue("abc"), pimtrace.SimpleIntegerValue(3), &pimtrace.SimpleNilValue{}),
+			InputArgs: []ValueExpression{EntryExpression("c.val")},
+			Output:    pimtrace.SimpleIntegerValue(3),
+			Err:       nil,
+		},
 	} {
 		t.Run(test.Name, func(t *testing.T) {
 			res, err := s.Run(test.Input, test.InputArgs, nil)
@@ -30,6 +30,10 @@ func (c Year[T]) Arguments() []ArgumentList {
 			Args:        []Argument{Integer},
 			Description: "Converts Unix time to a date and returns the year number of that date",
 		},
+		{
+			Args:        []Argument{Time},
+			Description: "Returns the year number of the date",
+		},
 	}
 }
 
@@ -64,6 +68,8 @@ func Arg1OnlyToTime[T ValueExpression](funcName string, d pimtrace.Entry, args [
 			return nil, fmt.Errorf("%s parse: %w", funcName, ErrNumberError)
 		}
 		t = time.Unix(int64(*i), 0)
+	case pimtrace.SimpleFloatValue, pimtrace.SimpleTimeValue:
+		t = *v.Time()
 	case pimtrace.SimpleStringValue:
 		s := v.String()
 		if s == "" {
@@ -62,6 +62,8 @@ func (y *YearAdapter) Call(args ...interface{}) (interface{}, error) {
 		if err != nil {
 			return nil, fmt.Errorf("parse time with locale detector: %w", err)
 		}
+	case pimtrace.SimpleTimeValue:
+		t = time.Time(v)
 	case pimtrace.Value: // Handle pimtrace.Value if args arrive as such
 		if i := v.Integer(); i != nil {
 			t = time.Unix(int64(*i), 0)
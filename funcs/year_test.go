@@ -195,8 +195,8 @@ func TestYear_NameAndArguments(t *testing.T) {
 		t.Errorf("Year.Name() = %v, want year", n)
 	}
 	args := y.Arguments()
-	if len(args) != 2 {
-		t.Errorf("Year.Arguments() returned %d arguments, want 2", len(args))
+	if len(args) != 3 {
+		t.Errorf("Year.Arguments() returned %d arguments, want 3", len(args))
 	}
 }
 
@@ -227,6 +227,17 @@ func TestYear_Run(t *testing.T) {
 		t.Errorf("Run() int expected 2025, got %v", res)
 	}
 
+	// Test time value keeps its own location
+	res, err = y.Run(d, []ValueExpression{
+		mockValueExpression{val: pimtrace.SimpleTimeValue(time.Date(2024, 1, 1, 5, 0, 0, 0, time.FixedZone("AEDT", 11*60*60)))},
+	}, nil)
+	if err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if v, ok := res.(pimtrace.SimpleIntegerValue); !ok || int(v) != 2024 {
+		t.Errorf("Run() time expected 2024, got %v", res)
+	}
+
 	// Test error case (empty)
 	res, err = y.Run(d, []ValueExpression{}, nil)
 	if err != nil {
@@ -260,6 +271,21 @@ func TestArgumentList_String(t *testing.T) {
 	}
 }
 
+func TestArgumentList_Signature(t *testing.T) {
+	al := ArgumentList{Args: []Argument{String, Bool}, Variadic: true}
+	if s := al.Signature("and"); s != "f.and[String,Bool...]" {
+		t.Errorf("Signature() = %v, want f.and[String,Bool...]", s)
+	}
+	for n, want := range []bool{false, false, true, true} {
+		if got := al.Accepts(n); got != want {
+			t.Errorf("Accepts(%d) = %v, want %v", n, got, want)
+		}
+	}
+	if (ArgumentList{}).Accepts(1) {
+		t.Errorf("Accepts(1) = true, want false for no arguments")
+	}
+}
+
 func TestYearAdapter_Call(t *testing.T) {
 	ya := &YearAdapter{}
 
@@ -0,0 +1,65 @@
+package pimtrace
+
+import (
+	"errors"
+	"io"
+)
+
+// Stream is Data which is read one entry at a time so it doesn't all have to be held in memory. Next returns io.EOF
+// once there are no entries left. NewSelf returns an empty Data of the type the stream's entries would be collected
+// into.
+type Stream interface {
+	Next() (Entry, error)
+	NewSelf() Data
+}
+
+// ColumnStream is a Stream which knows its column names before any entries are read, such as from a CSV header.
+type ColumnStream interface {
+	Stream
+	Columns() ([]string, error)
+}
+
+// Columns returns the column names of s, or nil if they aren't known ahead of reading it.
+func Columns(s Stream) ([]string, error) {
+	if cs, ok := s.(ColumnStream); ok {
+		return cs.Columns()
+	}
+	return nil, nil
+}
+
+type dataStream struct {
+	Data
+	pos int
+}
+
+// DataStream streams the entries of d.
+func DataStream(d Data) Stream {
+	return &dataStream{Data: d}
+}
+
+func (s *dataStream) Next() (Entry, error) {
+	if s.Data == nil || s.pos >= s.Data.Len() {
+		return nil, io.EOF
+	}
+	s.pos++
+	return s.Data.Entry(s.pos - 1), nil
+}
+
+// Collect reads the rest of s into memory.
+func Collect(s Stream) (Data, error) {
+	if ds, ok := s.(*dataStream); ok && ds.pos == 0 && ds.Data != nil {
+		ds.pos = ds.Data.Len()
+		return ds.Data, nil
+	}
+	d := s.NewSelf()
+	for {
+		e, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			return d, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		d = d.SetEntry(d.Len(), e)
+	}
+}
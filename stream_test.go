@@ -0,0 +1,80 @@
+package pimtrace
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+type testEntry int
+
+func (e testEntry) Get(string) (Value, error) { return SimpleIntegerValue(e), nil }
+
+type testData []Entry
+
+func (d testData) Len() int            { return len(d) }
+func (d testData) Entry(n int) Entry   { return d[n] }
+func (d testData) Truncate(n int) Data { return d[:n] }
+func (d testData) NewSelf() Data       { return testData{} }
+func (d testData) SetEntry(n int, e Entry) Data {
+	for len(d) <= n {
+		d = append(d, nil)
+	}
+	d[n] = e
+	return d
+}
+
+// opaqueStream hides the dataStream so Collect has to read it entry by entry.
+type opaqueStream struct {
+	Stream
+}
+
+func TestDataStream(t *testing.T) {
+	d := testData{testEntry(1), testEntry(2), testEntry(3)}
+	s := DataStream(d)
+	for i := range d {
+		e, err := s.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if e != d[i] {
+			t.Errorf("Next() = %v, want %v", e, d[i])
+		}
+	}
+	if _, err := s.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() error = %v, want io.EOF", err)
+	}
+	if _, err := DataStream(nil).Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("nil Next() error = %v, want io.EOF", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	d := testData{testEntry(1), testEntry(2), testEntry(3)}
+	for _, test := range []struct {
+		Name   string
+		Stream func() Stream
+		Want   int
+	}{
+		{Name: "Unread", Stream: func() Stream { return DataStream(d) }, Want: 3},
+		{Name: "Entry by entry", Stream: func() Stream { return opaqueStream{DataStream(d)} }, Want: 3},
+		{Name: "Partly read", Stream: func() Stream {
+			s := DataStream(d)
+			_, _ = s.Next()
+			return s
+		}, Want: 2},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := Collect(test.Stream())
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if got.Len() != test.Want {
+				t.Fatalf("Collect() has %d entries, want %d", got.Len(), test.Want)
+			}
+			if last := got.Entry(got.Len() - 1); last != testEntry(3) {
+				t.Errorf("Collect() last entry = %v, want 3", last)
+			}
+		})
+	}
+}
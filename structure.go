@@ -1,11 +1,15 @@
 package pimtrace
 
 import (
+	"cmp"
 	"fmt"
+	"math"
 	mail2 "net/mail"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/araddon/dateparse"
 )
 
 type SimpleStringValue string
@@ -31,18 +35,17 @@ func (s SimpleStringValue) StringArray() []string {
 }
 
 func (s SimpleStringValue) Less(jv Value) bool {
-	switch jv := jv.(type) {
-	case SimpleStringValue:
-		return strings.Compare(string(s), string(jv)) < 0
-	default:
-		return strings.Compare(string(s), jv.String()) < 0
-	}
+	return Compare(s, jv) < 0
 }
 
+// Equal compares text with text exactly, so "20" and "20.0" differ although Compare collates them together, and
+// compares text with a number numerically as Compare does.
 func (s SimpleStringValue) Equal(jv Value) bool {
 	switch jv := jv.(type) {
 	case SimpleStringValue:
 		return string(s) == string(jv)
+	case SimpleIntegerValue, SimpleFloatValue:
+		return jv.Equal(s)
 	default:
 		return s.String() == jv.String()
 	}
@@ -107,12 +110,7 @@ func (s *SimpleNilValue) StringArray() []string {
 }
 
 func (s *SimpleNilValue) Less(jv Value) bool {
-	switch jv.(type) {
-	case *SimpleNilValue:
-		return false
-	default:
-		return strings.Compare(s.String(), jv.String()) < 0
-	}
+	return Compare(s, jv) < 0
 }
 
 func (s *SimpleNilValue) Equal(jv Value) bool {
@@ -169,19 +167,19 @@ func (s SimpleIntegerValue) StringArray() []string {
 }
 
 func (s SimpleIntegerValue) Less(jv Value) bool {
-	switch jv := jv.(type) {
-	case SimpleIntegerValue:
-		return s < jv
-	default:
-		return strings.Compare(s.String(), jv.String()) < 0
-	}
+	return Compare(s, jv) < 0
 }
 
 func (s SimpleIntegerValue) Equal(jv Value) bool {
 	switch jv := jv.(type) {
 	case SimpleIntegerValue:
 		return s == jv
+	case SimpleFloatValue:
+		return float64(s) == float64(jv)
 	default:
+		if n := NumericValue(jv); n != nil {
+			return compareNumbers(s, n) == 0
+		}
 		return s.String() == jv.String()
 	}
 }
@@ -211,6 +209,263 @@ func (s SimpleIntegerValue) String() string {
 
 var _ Value = SimpleIntegerValue(0)
 
+type SimpleFloatValue float64
+
+func (s SimpleFloatValue) Truthy() bool {
+	return s != 0
+}
+
+func (s SimpleFloatValue) Elements() int {
+	return 1
+}
+
+func (s SimpleFloatValue) Length() int {
+	return 1
+}
+
+func (s SimpleFloatValue) Array() []Value {
+	return []Value{s}
+}
+
+func (s SimpleFloatValue) StringArray() []string {
+	return []string{s.String()}
+}
+
+func (s SimpleFloatValue) Less(jv Value) bool {
+	return Compare(s, jv) < 0
+}
+
+func (s SimpleFloatValue) Equal(jv Value) bool {
+	switch jv := jv.(type) {
+	case SimpleFloatValue:
+		return s == jv
+	case SimpleIntegerValue:
+		return float64(s) == float64(jv)
+	default:
+		if n := NumericValue(jv); n != nil {
+			return compareNumbers(s, n) == 0
+		}
+		return s.String() == jv.String()
+	}
+}
+
+func (s SimpleFloatValue) Time() *time.Time {
+	sec, frac := math.Modf(float64(s))
+	ut := time.Unix(int64(sec), int64(frac*1e9))
+	return &ut
+}
+
+func (s SimpleFloatValue) Integer() *int {
+	si := int(s)
+	return &si
+}
+
+func (s SimpleFloatValue) Float64() *float64 {
+	si := float64(s)
+	return &si
+}
+
+func (s SimpleFloatValue) Type() Type {
+	return Float
+}
+
+func (s SimpleFloatValue) String() string {
+	return strconv.FormatFloat(float64(s), 'f', -1, 64)
+}
+
+var _ Value = SimpleFloatValue(0)
+
+// NumericValue returns v as a SimpleIntegerValue or SimpleFloatValue, or nil if v isn't a number. Strings are parsed
+// as integers first so that "150" stays an integer while "150.50" becomes a float. Only decimal numbers count, so text
+// such as "nan", "inf" or "0x10" stays text.
+func NumericValue(v Value) Value {
+	switch v := v.(type) {
+	case SimpleIntegerValue, SimpleFloatValue:
+		return v
+	case SimpleStringValue:
+		if !isDecimal(string(v)) {
+			return nil
+		}
+		if i := v.Integer(); i != nil {
+			return SimpleIntegerValue(*i)
+		}
+		if f := v.Float64(); f != nil && !math.IsInf(*f, 0) {
+			return SimpleFloatValue(*f)
+		}
+	}
+	return nil
+}
+
+// isDecimal reports if s is a number in decimal syntax: an optional sign, digits with an optional fraction, and an
+// optional exponent.
+func isDecimal(s string) bool {
+	sign := func() {
+		if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+	}
+	sign()
+	digits := func() int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		s = s[n:]
+		return n
+	}
+	n := digits()
+	if strings.HasPrefix(s, ".") {
+		s = s[1:]
+		n += digits()
+	}
+	if n == 0 {
+		return false
+	}
+	if len(s) > 0 && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		sign()
+		if digits() == 0 {
+			return false
+		}
+	}
+	return s == ""
+}
+
+type SimpleTimeValue time.Time
+
+func (s SimpleTimeValue) Truthy() bool {
+	return !time.Time(s).IsZero()
+}
+
+func (s SimpleTimeValue) Elements() int {
+	return 1
+}
+
+func (s SimpleTimeValue) Length() int {
+	return 1
+}
+
+func (s SimpleTimeValue) Array() []Value {
+	return []Value{s}
+}
+
+func (s SimpleTimeValue) StringArray() []string {
+	return []string{s.String()}
+}
+
+func (s SimpleTimeValue) Less(jv Value) bool {
+	return Compare(s, jv) < 0
+}
+
+func (s SimpleTimeValue) Equal(jv Value) bool {
+	return Compare(s, jv) == 0
+}
+
+func (s SimpleTimeValue) Time() *time.Time {
+	t := time.Time(s)
+	return &t
+}
+
+func (s SimpleTimeValue) Integer() *int {
+	si := int(time.Time(s).Unix())
+	return &si
+}
+
+func (s SimpleTimeValue) Float64() *float64 {
+	si := float64(time.Time(s).UnixNano()) / 1e9
+	return &si
+}
+
+func (s SimpleTimeValue) Type() Type {
+	return Time
+}
+
+func (s SimpleTimeValue) String() string {
+	return time.Time(s).Format(time.RFC3339)
+}
+
+var _ Value = SimpleTimeValue{}
+
+// ParseTime parses a date as found in mail headers, falling back to dateparse for everything else. Returns nil if the
+// string isn't recognised as a date.
+func ParseTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if t, err := mail2.ParseDate(s); err == nil {
+		return &t
+	}
+	if t, err := dateparse.ParseAny(s); err == nil {
+		return &t
+	}
+	return nil
+}
+
+type SimpleBoolValue bool
+
+func (s SimpleBoolValue) Truthy() bool {
+	return bool(s)
+}
+
+func (s SimpleBoolValue) Elements() int {
+	return 1
+}
+
+func (s SimpleBoolValue) Length() int {
+	return 1
+}
+
+func (s SimpleBoolValue) Array() []Value {
+	return []Value{s}
+}
+
+func (s SimpleBoolValue) StringArray() []string {
+	return []string{s.String()}
+}
+
+func (s SimpleBoolValue) Less(jv Value) bool {
+	return Compare(s, jv) < 0
+}
+
+func (s SimpleBoolValue) Equal(jv Value) bool {
+	switch jv := jv.(type) {
+	case SimpleBoolValue:
+		return s == jv
+	case nil:
+		return false
+	default:
+		return s.String() == jv.String()
+	}
+}
+
+func (s SimpleBoolValue) Time() *time.Time {
+	return nil
+}
+
+func (s SimpleBoolValue) Integer() *int {
+	si := 0
+	if s {
+		si = 1
+	}
+	return &si
+}
+
+func (s SimpleBoolValue) Float64() *float64 {
+	sf := float64(*s.Integer())
+	return &sf
+}
+
+func (s SimpleBoolValue) Type() Type {
+	return Bool
+}
+
+func (s SimpleBoolValue) String() string {
+	return strconv.FormatBool(bool(s))
+}
+
+var _ Value = SimpleBoolValue(false)
+
 type SimpleArrayValue []Value
 
 func (s SimpleArrayValue) Truthy() bool {
@@ -230,23 +485,7 @@ func (s SimpleArrayValue) Array() []Value {
 }
 
 func (s SimpleArrayValue) Less(jv Value) bool {
-	switch jv := jv.(type) {
-	case SimpleArrayValue:
-		for i := 0; i < len(s) || i < len(jv); i++ {
-			if len(s) >= i {
-				return false
-			}
-			if len(jv) >= i {
-				return true
-			}
-			if s[i].Less(jv[i]) {
-				return true
-			}
-		}
-		return false
-	default:
-		return strings.Compare(s.String(), jv.String()) < 0
-	}
+	return Compare(s, jv) < 0
 }
 
 func (s SimpleArrayValue) Equal(jv Value) bool {
@@ -256,11 +495,18 @@ func (s SimpleArrayValue) Equal(jv Value) bool {
 			return false
 		}
 		for i := 0; i < len(s); i++ {
-			if s[i] != jv[i] {
+			switch {
+			case isNilValue(s[i]) || isNilValue(jv[i]):
+				if isNilValue(s[i]) != isNilValue(jv[i]) {
+					return false
+				}
+			case !s[i].Equal(jv[i]):
 				return false
 			}
 		}
 		return true
+	case nil:
+		return false
 	default:
 		return s.String() == jv.String()
 	}
@@ -282,12 +528,154 @@ func (s SimpleArrayValue) Type() Type {
 	return Array
 }
 
+// ArraySeparator is placed between the elements of an array when it's rendered as a single cell.
+var ArraySeparator = ", "
+
 func (s SimpleArrayValue) String() string {
-	return fmt.Sprintf("%#v", s)
+	parts := make([]string, len(s))
+	for i, v := range s {
+		if v != nil {
+			parts[i] = v.String()
+		}
+	}
+	return strings.Join(parts, ArraySeparator)
+}
+
+// Key returns a canonical encoding of v which is equal for two values only if they have the same type and content.
+// Unlike String it doesn't depend on ArraySeparator and can't be confused by separators within the elements.
+func Key(v Value) string {
+	var b strings.Builder
+	writeKey(&b, v)
+	return b.String()
+}
+
+func writeKey(b *strings.Builder, v Value) {
+	if isNilValue(v) {
+		b.WriteString("n;")
+		return
+	}
+	switch v := v.(type) {
+	case SimpleArrayValue:
+		_, _ = fmt.Fprintf(b, "a%d[", len(v))
+		for _, e := range v {
+			writeKey(b, e)
+		}
+		b.WriteString("]")
+	default:
+		str := v.String()
+		_, _ = fmt.Fprintf(b, "%c%d:%s", v.Type().String()[0], len(str), str)
+	}
 }
 
 var _ Value = SimpleArrayValue(nil)
 
+// Compare orders a against b returning a negative number if a sorts first, zero if they collate equally and a
+// positive number if b sorts first. It's the collation used by sort and the comparison filter operators:
+//
+//	Kind    Values                                 Ordered
+//	Nil     Nil values (and missing values)        First, equal to each other
+//	Bool    Bool                                   false before true, Strings "true" and "false" are compared as Bool
+//	Number  Integer, Float and numeric Strings     Numerically, so "20" is before "100"
+//	Time    Time                                   Chronologically, Strings which parse as dates are compared as Time
+//	String  Everything else                        Byte-wise
+//	Array   Array                                  Element by element, a shorter prefix first; other values compare
+//	                                               as a one element Array
+//
+// Values of different kinds sort in the order listed, so booleans come before numbers, numbers before times and times
+// before text.
+func Compare(a, b Value) int {
+	an, bn := isNilValue(a), isNilValue(b)
+	switch {
+	case an && bn:
+		return 0
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	if a.Type() == Array || b.Type() == Array {
+		return compareArrays(a.Array(), b.Array())
+	}
+	if a.Type() == Bool || b.Type() == Bool {
+		if ab, bb := collationBool(a), collationBool(b); ab != nil && bb != nil {
+			return cmp.Compare(*ab.Integer(), *bb.Integer())
+		}
+	}
+	if a.Type() == Time || b.Type() == Time {
+		if at, bt := collationTime(a), collationTime(b); at != nil && bt != nil {
+			return at.Compare(*bt)
+		}
+	}
+	if an, bn := NumericValue(a), NumericValue(b); an != nil && bn != nil {
+		return compareNumbers(an, bn)
+	}
+	if ar, br := collationRank(a), collationRank(b); ar != br {
+		return ar - br
+	}
+	return strings.Compare(a.String(), b.String())
+}
+
+func isNilValue(v Value) bool {
+	return v == nil || v.Type() == Nil
+}
+
+func collationRank(v Value) int {
+	switch {
+	case isNilValue(v):
+		return 0
+	case v.Type() == Bool:
+		return 1
+	case NumericValue(v) != nil:
+		return 2
+	case v.Type() == Time:
+		return 3
+	}
+	return 4
+}
+
+func collationBool(v Value) Value {
+	switch v.Type() {
+	case Bool:
+		return v
+	case String:
+		switch strings.ToLower(v.String()) {
+		case "true":
+			return SimpleBoolValue(true)
+		case "false":
+			return SimpleBoolValue(false)
+		}
+	}
+	return nil
+}
+
+func collationTime(v Value) *time.Time {
+	switch v.Type() {
+	case Time:
+		return v.Time()
+	case String:
+		return ParseTime(v.String())
+	}
+	return nil
+}
+
+func compareNumbers(a, b Value) int {
+	ai, aok := a.(SimpleIntegerValue)
+	bi, bok := b.(SimpleIntegerValue)
+	if aok && bok {
+		return cmp.Compare(ai, bi)
+	}
+	return cmp.Compare(*a.Float64(), *b.Float64())
+}
+
+func compareArrays(a, b []Value) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
 type Type int
 
 const (
@@ -295,6 +683,9 @@ const (
 	Integer
 	Array
 	Nil
+	Float
+	Time
+	Bool
 )
 
 func (t Type) String() string {
@@ -305,6 +696,14 @@ func (t Type) String() string {
 		return "Integer"
 	case Array:
 		return "Array"
+	case Nil:
+		return "Nil"
+	case Float:
+		return "Float"
+	case Time:
+		return "Time"
+	case Bool:
+		return "Bool"
 	}
 	return "unknown"
 }
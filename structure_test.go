@@ -0,0 +1,245 @@
+package pimtrace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimpleFloatValue(t *testing.T) {
+	f := SimpleFloatValue(150.5)
+	if f.String() != "150.5" {
+		t.Errorf("String() = %q, want 150.5", f.String())
+	}
+	if f.Type() != Float {
+		t.Errorf("Type() = %s, want Float", f.Type())
+	}
+	if !f.Truthy() || SimpleFloatValue(0).Truthy() {
+		t.Errorf("Truthy() incorrect")
+	}
+	if i := f.Integer(); i == nil || *i != 150 {
+		t.Errorf("Integer() = %v, want 150", i)
+	}
+	if !SimpleFloatValue(2).Equal(SimpleIntegerValue(2)) || !SimpleIntegerValue(2).Equal(SimpleFloatValue(2)) {
+		t.Errorf("Equal() across Integer and Float should be true")
+	}
+	if !SimpleIntegerValue(2).Less(SimpleFloatValue(2.5)) || !SimpleFloatValue(2.5).Less(SimpleIntegerValue(10)) {
+		t.Errorf("Less() across Integer and Float should compare numerically")
+	}
+}
+
+func TestNumericValue(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Input  Value
+		Output Value
+	}{
+		{Name: "Integer", Input: SimpleIntegerValue(3), Output: SimpleIntegerValue(3)},
+		{Name: "Float", Input: SimpleFloatValue(3.5), Output: SimpleFloatValue(3.5)},
+		{Name: "Integer string", Input: SimpleStringValue("150"), Output: SimpleIntegerValue(150)},
+		{Name: "Float string", Input: SimpleStringValue("150.50"), Output: SimpleFloatValue(150.5)},
+		{Name: "Exponent string", Input: SimpleStringValue("-1.5e2"), Output: SimpleFloatValue(-150)},
+		{Name: "Fraction only string", Input: SimpleStringValue(".5"), Output: SimpleFloatValue(0.5)},
+		{Name: "Text", Input: SimpleStringValue("abc"), Output: nil},
+		{Name: "NaN text", Input: SimpleStringValue("nan"), Output: nil},
+		{Name: "Inf text", Input: SimpleStringValue("Inf"), Output: nil},
+		{Name: "Infinity text", Input: SimpleStringValue("-infinity"), Output: nil},
+		{Name: "Hex text", Input: SimpleStringValue("0x10"), Output: nil},
+		{Name: "Out of range", Input: SimpleStringValue("1e400"), Output: nil},
+		{Name: "Lone sign", Input: SimpleStringValue("-"), Output: nil},
+		{Name: "Nil", Input: &SimpleNilValue{}, Output: nil},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := NumericValue(test.Input); got != test.Output {
+				t.Errorf("NumericValue() = %#v, want %#v", got, test.Output)
+			}
+		})
+	}
+}
+
+func TestSimpleTimeValue(t *testing.T) {
+	earlier := SimpleTimeValue(time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC))
+	later := SimpleTimeValue(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+	if !earlier.Less(later) || later.Less(earlier) {
+		t.Errorf("Less() should be chronological")
+	}
+	sameInstant := SimpleTimeValue(time.Date(2023, 2, 1, 11, 0, 0, 0, time.FixedZone("AEDT", 11*60*60)))
+	if !earlier.Equal(sameInstant) {
+		t.Errorf("Equal() should compare instants")
+	}
+	day := SimpleTimeValue(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !day.Equal(SimpleStringValue("2024-01-01")) || day.Equal(SimpleStringValue("2024-01-02")) {
+		t.Errorf("Equal() should compare text as a time as Compare does")
+	}
+	if earlier.Type() != Time {
+		t.Errorf("Type() = %s, want Time", earlier.Type())
+	}
+	if SimpleTimeValue(time.Time{}).Truthy() {
+		t.Errorf("zero time should not be truthy")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	for _, test := range []struct {
+		Input string
+		Want  *time.Time
+	}{
+		{Input: "Thu, 13 Feb 1969 23:32:54 -0330", Want: func() *time.Time {
+			t := time.Date(1969, 2, 13, 23, 32, 54, 0, time.FixedZone("", -(3*60+30)*60))
+			return &t
+		}()},
+		{Input: "2023-10-27", Want: func() *time.Time {
+			t := time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC)
+			return &t
+		}()},
+		{Input: "", Want: nil},
+		{Input: "not a date", Want: nil},
+	} {
+		t.Run(test.Input, func(t *testing.T) {
+			got := ParseTime(test.Input)
+			if (got == nil) != (test.Want == nil) || (got != nil && !got.Equal(*test.Want)) {
+				t.Errorf("ParseTime(%q) = %v, want %v", test.Input, got, test.Want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	date := SimpleTimeValue(time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC))
+	for _, test := range []struct {
+		Name string
+		A    Value
+		B    Value
+		Want int
+	}{
+		{Name: "Numeric strings compare numerically", A: SimpleStringValue("20"), B: SimpleStringValue("100"), Want: -1},
+		{Name: "Integer against numeric string", A: SimpleIntegerValue(100), B: SimpleStringValue("20"), Want: 1},
+		{Name: "Float against Integer", A: SimpleFloatValue(1.5), B: SimpleIntegerValue(2), Want: -1},
+		{Name: "Equal numbers of different types", A: SimpleStringValue("2.0"), B: SimpleIntegerValue(2), Want: 0},
+		{Name: "Nil before numbers", A: &SimpleNilValue{}, B: SimpleIntegerValue(-5), Want: -1},
+		{Name: "Nil before text", A: SimpleStringValue(""), B: &SimpleNilValue{}, Want: 1},
+		{Name: "Go nil is Nil", A: nil, B: &SimpleNilValue{}, Want: 0},
+		{Name: "Numbers before text", A: SimpleStringValue("abc"), B: SimpleIntegerValue(5), Want: 1},
+		{Name: "NaN text is text", A: SimpleStringValue("nan"), B: SimpleIntegerValue(5), Want: 1},
+		{Name: "Numbers before times", A: SimpleIntegerValue(5), B: date, Want: -1},
+		{Name: "Times before text", A: date, B: SimpleStringValue("abc"), Want: -1},
+		{Name: "Date strings compare to times", A: SimpleStringValue("2023-01-01"), B: date, Want: -1},
+		{Name: "Text compares byte-wise", A: SimpleStringValue("apple"), B: SimpleStringValue("banana"), Want: -1},
+		{Name: "Arrays compare element-wise", A: SimpleArrayValue{SimpleIntegerValue(1), SimpleStringValue("20")}, B: SimpleArrayValue{SimpleIntegerValue(1), SimpleStringValue("100")}, Want: -1},
+		{Name: "Shorter array prefix first", A: SimpleArrayValue{SimpleIntegerValue(1)}, B: SimpleArrayValue{SimpleIntegerValue(1), SimpleIntegerValue(0)}, Want: -1},
+		{Name: "Scalar against array", A: SimpleIntegerValue(2), B: SimpleArrayValue{SimpleIntegerValue(10)}, Want: -1},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got := Compare(test.A, test.B)
+			if (got < 0) != (test.Want < 0) || (got > 0) != (test.Want > 0) {
+				t.Errorf("Compare() = %d, want %d", got, test.Want)
+			}
+			if back := Compare(test.B, test.A); (back < 0) != (test.Want > 0) || (back > 0) != (test.Want < 0) {
+				t.Errorf("Compare() reversed = %d, want %d", back, -test.Want)
+			}
+		})
+	}
+}
+
+func TestEqualAgreesWithCompare(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		A    Value
+		B    Value
+		Want bool
+	}{
+		{Name: "Integer and float string", A: SimpleIntegerValue(20), B: SimpleStringValue("20.0"), Want: true},
+		{Name: "Float and integer string", A: SimpleFloatValue(1.5), B: SimpleStringValue("1.50"), Want: true},
+		{Name: "Integer and other number string", A: SimpleIntegerValue(20), B: SimpleStringValue("21"), Want: false},
+		{Name: "Integer and text", A: SimpleIntegerValue(20), B: SimpleStringValue("twenty"), Want: false},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := test.A.Equal(test.B); got != test.Want {
+				t.Errorf("Equal() = %v, want %v", got, test.Want)
+			}
+			if got := test.B.Equal(test.A); got != test.Want {
+				t.Errorf("Equal() reversed = %v, want %v", got, test.Want)
+			}
+			if got := Compare(test.A, test.B) == 0; got != test.Want {
+				t.Errorf("Compare() == 0 is %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSimpleArrayValue(t *testing.T) {
+	a := SimpleArrayValue{SimpleStringValue("x"), SimpleIntegerValue(2), &SimpleNilValue{}}
+	if !a.Equal(SimpleArrayValue{SimpleStringValue("x"), SimpleStringValue("2"), &SimpleNilValue{}}) {
+		t.Errorf("Equal() should compare elements by value")
+	}
+	if a.Equal(SimpleArrayValue{SimpleStringValue("x"), SimpleIntegerValue(3), &SimpleNilValue{}}) {
+		t.Errorf("Equal() matched differing elements")
+	}
+	if a.Equal(SimpleArrayValue{SimpleStringValue("x"), SimpleIntegerValue(2)}) {
+		t.Errorf("Equal() matched differing lengths")
+	}
+	if a.Equal(nil) {
+		t.Errorf("Equal(nil) should be false")
+	}
+	if !a.Less(SimpleArrayValue{SimpleStringValue("y")}) || a.Less(SimpleArrayValue{SimpleStringValue("x")}) {
+		t.Errorf("Less() isn't lexicographic")
+	}
+	if got, want := a.String(), "x, 2, "; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	defer func(sep string) { ArraySeparator = sep }(ArraySeparator)
+	ArraySeparator = "|"
+	if got, want := a.String(), "x|2|"; got != want {
+		t.Errorf("String() with separator = %q, want %q", got, want)
+	}
+}
+
+func TestKey(t *testing.T) {
+	for _, test := range []struct {
+		Name  string
+		A     Value
+		B     Value
+		Equal bool
+	}{
+		{Name: "Same contents", A: SimpleArrayValue{SimpleStringValue("a"), SimpleIntegerValue(1)}, B: SimpleArrayValue{SimpleStringValue("a"), SimpleIntegerValue(1)}, Equal: true},
+		{Name: "Separator inside element", A: SimpleArrayValue{SimpleStringValue("a, b")}, B: SimpleArrayValue{SimpleStringValue("a"), SimpleStringValue("b")}},
+		{Name: "Nested arrays", A: SimpleArrayValue{SimpleArrayValue{SimpleStringValue("a")}, SimpleStringValue("b")}, B: SimpleArrayValue{SimpleArrayValue{SimpleStringValue("a"), SimpleStringValue("b")}}},
+		{Name: "Type matters", A: SimpleIntegerValue(1), B: SimpleStringValue("1")},
+		{Name: "Nil and empty string", A: SimpleArrayValue{&SimpleNilValue{}}, B: SimpleArrayValue{SimpleStringValue("")}},
+		{Name: "Go nil and Nil", A: SimpleArrayValue{nil}, B: SimpleArrayValue{&SimpleNilValue{}}, Equal: true},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := Key(test.A) == Key(test.B); got != test.Equal {
+				t.Errorf("Key(%q) == Key(%q) is %v, want %v", Key(test.A), Key(test.B), got, test.Equal)
+			}
+		})
+	}
+}
+
+func TestSimpleBoolValue(t *testing.T) {
+	if got := SimpleBoolValue(true).String(); got != "true" {
+		t.Errorf("String() = %q, want true", got)
+	}
+	if SimpleBoolValue(false).Truthy() {
+		t.Errorf("Truthy() of false should be false")
+	}
+	if !SimpleBoolValue(true).Equal(SimpleStringValue("true")) {
+		t.Errorf("Equal() should match the rendered string")
+	}
+	if *SimpleBoolValue(true).Integer() != 1 || *SimpleBoolValue(false).Integer() != 0 {
+		t.Errorf("Integer() should be 1 or 0")
+	}
+	for _, test := range []struct {
+		A    Value
+		B    Value
+		Want int
+	}{
+		{A: SimpleBoolValue(false), B: SimpleBoolValue(true), Want: -1},
+		{A: SimpleBoolValue(true), B: SimpleStringValue("TRUE"), Want: 0},
+		{A: &SimpleNilValue{}, B: SimpleBoolValue(false), Want: -1},
+		{A: SimpleBoolValue(true), B: SimpleIntegerValue(0), Want: -1},
+	} {
+		if got := Compare(test.A, test.B); got != test.Want {
+			t.Errorf("Compare(%v, %v) = %d, want %d", test.A, test.B, got, test.Want)
+		}
+	}
+}